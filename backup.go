@@ -0,0 +1,257 @@
+package hcat
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// backupSuffix separates the rendered file name from the generation
+	// number or timestamp in backup file names.
+	backupSuffix = ".bak."
+
+	// backupTimeFormat is the format for timestamped backups. It sorts
+	// lexically in time order.
+	backupTimeFormat = "20060102T150405.000000000Z"
+)
+
+// ErrNoBackup is returned by Rollback when there is no backup to restore.
+var ErrNoBackup = errors.New("no backup to restore")
+
+// Backups manages multiple generations of backups of rendered files. Use its
+// Backup method as the FileRenderer's BackupFunc and Rollback to restore the
+// previous generation (eg. when a post-render validation step fails).
+//
+// Backups are named [filename].bak.[N], where 1 is the newest, or
+// [filename].bak.[timestamp] if timestamped. Backups stored in a backup
+// directory are named after the full path of the file instead, escaped, so
+// files with the same name in different directories are kept apart.
+type Backups struct {
+	generations int
+	timestamp   bool
+	dir         string
+}
+
+// BackupsInput is the input structure for NewBackups.
+type BackupsInput struct {
+	// Generations is the number of backups to retain per file (default 1)
+	Generations int
+	// Timestamp names backups with the (UTC) time of the backup instead of
+	// the generation number
+	Timestamp bool
+	// Dir is the directory to store backups in. It defaults to the directory
+	// of the rendered file and is created if missing.
+	Dir string
+}
+
+// NewBackups returns a new Backups.
+func NewBackups(i BackupsInput) *Backups {
+	generations := i.Generations
+	if generations < 1 {
+		generations = 1
+	}
+	return &Backups{
+		generations: generations,
+		timestamp:   i.Timestamp,
+		dir:         i.Dir,
+	}
+}
+
+// Backup makes a new backup of the file at path and removes the generations
+// past those retained. It matches the BackupFunc signature and like Backup
+// any errors are ignored.
+func (b *Backups) Backup(path string) {
+	b.backup(path) // ignore error
+}
+
+// Rollback restores the newest backup of the file at path, replacing the
+// file. The restored backup is removed, so the backup before it becomes the
+// newest. Returns ErrNoBackup if there are no backups.
+func (b *Backups) Rollback(path string) error {
+	backups, err := b.List(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return ErrNoBackup
+	}
+
+	if err := restore(backups[0], path); err != nil {
+		return errors.Wrap(err, "rollback")
+	}
+
+	// numbered backups are shifted down to keep the newest at 1
+	if !b.timestamp {
+		for i, bak := range backups[1:] {
+			if err := os.Rename(bak, b.name(path, strconv.Itoa(i+1))); err != nil {
+				return errors.Wrap(err, "rollback")
+			}
+		}
+	}
+	return nil
+}
+
+// List returns the paths of the backups of the file at path, newest first.
+func (b *Backups) List(path string) ([]string, error) {
+	prefix := b.baseName(path) + backupSuffix
+	files, err := ioutil.ReadDir(b.backupDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	type backup struct {
+		path string
+		gen  int
+	}
+	var backups []backup
+	for _, f := range files {
+		if f.IsDir() || !strings.HasPrefix(f.Name(), prefix) {
+			continue
+		}
+		bak := backup{path: filepath.Join(b.backupDir(path), f.Name())}
+		id := strings.TrimPrefix(f.Name(), prefix)
+		if b.timestamp {
+			if _, err := time.Parse(backupTimeFormat, id); err != nil {
+				continue
+			}
+		} else {
+			gen, err := strconv.Atoi(id)
+			if err != nil || gen < 1 {
+				continue
+			}
+			bak.gen = gen
+		}
+		backups = append(backups, bak)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if b.timestamp {
+			return backups[i].path > backups[j].path
+		}
+		return backups[i].gen < backups[j].gen
+	})
+
+	paths := make([]string, len(backups))
+	for i, bak := range backups {
+		paths[i] = bak.path
+	}
+	return paths, nil
+}
+
+func (b *Backups) backup(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(b.backupDir(path), defaultDirPerms); err != nil {
+		return err
+	}
+
+	backups, err := b.List(path)
+	if err != nil {
+		return err
+	}
+
+	var bak string
+	if b.timestamp {
+		bak = b.name(path, time.Now().UTC().Format(backupTimeFormat))
+	} else {
+		// shift older generations up, oldest first
+		for i := len(backups) - 1; i >= 0; i-- {
+			if err := os.Rename(backups[i], b.name(path, strconv.Itoa(i+2))); err != nil {
+				return err
+			}
+			backups[i] = b.name(path, strconv.Itoa(i+2))
+		}
+		bak = b.name(path, "1")
+	}
+
+	if err := linkOrCopy(path, bak); err != nil {
+		return err
+	}
+
+	// backups holds the generations prior to this one, newest first
+	for i := b.generations - 1; i < len(backups); i++ {
+		os.Remove(backups[i]) // ignore error
+	}
+	return nil
+}
+
+// backupDir is the directory the backups of path are stored in.
+func (b *Backups) backupDir(path string) string {
+	if b.dir != "" {
+		return b.dir
+	}
+	return filepath.Dir(path)
+}
+
+// baseName is the name of the backups of path, before the generation id.
+func (b *Backups) baseName(path string) string {
+	if b.dir == "" {
+		return filepath.Base(path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return url.QueryEscape(filepath.ToSlash(filepath.Clean(path)))
+}
+
+// name returns the backup file path for path with the given generation id.
+func (b *Backups) name(path, id string) string {
+	return filepath.Join(b.backupDir(path), b.baseName(path)+backupSuffix+id)
+}
+
+// linkOrCopy hardlinks src to dst (like Backup), falling back to copying for
+// backup directories on other filesystems. The mode is preserved.
+func linkOrCopy(src, dst string) error {
+	os.Remove(dst) // ignore error, link fails if dst exists
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	contents, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return atomicWrite(dst, contents, fileAttrs{perms: info.Mode()})
+}
+
+// restore moves the backup to path, copying it if on another filesystem.
+func restore(backup, path string) error {
+	if err := os.Rename(backup, path); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(backup)
+	if err != nil {
+		return err
+	}
+	contents, err := ioutil.ReadFile(backup)
+	if err != nil {
+		return err
+	}
+	if err := atomicWrite(path, contents, fileAttrs{perms: info.Mode()}); err != nil {
+		return err
+	}
+	return os.Remove(backup)
+}
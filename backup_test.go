@@ -0,0 +1,240 @@
+package hcat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackups(t *testing.T) {
+	// renders the contents in order with the backups, like FileRenderer
+	renders := func(t *testing.T, b *Backups, path string, contents ...string) {
+		for _, c := range contents {
+			b.Backup(path)
+			if err := atomicWrite(path, []byte(c), fileAttrs{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	contents := func(t *testing.T, paths ...string) []string {
+		result := make([]string, len(paths))
+		for i, p := range paths {
+			c, err := ioutil.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result[i] = string(c)
+		}
+		return result
+	}
+	check := func(t *testing.T, exp, act []string) {
+		if strings.Join(exp, ",") != strings.Join(act, ",") {
+			t.Errorf("\nexp: %v\nact: %v", exp, act)
+		}
+	}
+
+	t.Run("generations", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		b := NewBackups(BackupsInput{Generations: 3})
+		renders(t, b, path, "1", "2", "3", "4", "5")
+
+		list, err := b.List(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{
+			filepath.Join(outDir, "out.bak.1"),
+			filepath.Join(outDir, "out.bak.2"),
+			filepath.Join(outDir, "out.bak.3"),
+		}, list)
+		check(t, []string{"4", "3", "2"}, contents(t, list...))
+	})
+
+	t.Run("default-generations", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		b := NewBackups(BackupsInput{})
+		renders(t, b, path, "1", "2", "3")
+
+		list, err := b.List(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{"2"}, contents(t, list...))
+	})
+
+	t.Run("timestamp-dir", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+		bakDir := filepath.Join(outDir, "backups")
+
+		b := NewBackups(BackupsInput{
+			Generations: 2, Timestamp: true, Dir: bakDir})
+		renders(t, b, path, "1", "2", "3", "4")
+
+		list, err := b.List(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("expected 2 backups, got %v", list)
+		}
+		for _, p := range list {
+			if filepath.Dir(p) != bakDir {
+				t.Errorf("backup %q not in backup dir", p)
+			}
+		}
+		check(t, []string{"3", "2"}, contents(t, list...))
+	})
+
+	t.Run("dir-same-name", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		bakDir := filepath.Join(outDir, "backups")
+		pathA := filepath.Join(outDir, "a", "config.json")
+		pathB := filepath.Join(outDir, "b", "config.json")
+		for _, p := range []string{pathA, pathB} {
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		b := NewBackups(BackupsInput{Generations: 2, Dir: bakDir})
+		renders(t, b, pathA, "a1", "a2", "a3")
+		renders(t, b, pathB, "b1", "b2")
+
+		listA, err := b.List(pathA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{"a2", "a1"}, contents(t, listA...))
+		listB, err := b.List(pathB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{"b1"}, contents(t, listB...))
+
+		if err := b.Rollback(pathA); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Rollback(pathB); err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{"a2", "b1"}, contents(t, pathA, pathB))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		for _, ts := range []bool{false, true} {
+			outDir, err := ioutil.TempDir("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(outDir)
+			path := filepath.Join(outDir, "out")
+
+			b := NewBackups(BackupsInput{Generations: 3, Timestamp: ts})
+			renders(t, b, path, "1", "2", "3")
+
+			if err := b.Rollback(path); err != nil {
+				t.Fatal(err)
+			}
+			check(t, []string{"2"}, contents(t, path))
+			if err := b.Rollback(path); err != nil {
+				t.Fatal(err)
+			}
+			check(t, []string{"1"}, contents(t, path))
+			if err := b.Rollback(path); err != ErrNoBackup {
+				t.Fatalf("expected %v, got %v", ErrNoBackup, err)
+			}
+			check(t, []string{"1"}, contents(t, path))
+		}
+	})
+
+	t.Run("rollback-renumbers", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		b := NewBackups(BackupsInput{Generations: 3})
+		renders(t, b, path, "1", "2", "3", "4")
+		if err := b.Rollback(path); err != nil {
+			t.Fatal(err)
+		}
+
+		list, err := b.List(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{
+			filepath.Join(outDir, "out.bak.1"),
+			filepath.Join(outDir, "out.bak.2"),
+		}, list)
+		check(t, []string{"2", "1"}, contents(t, list...))
+	})
+
+	t.Run("renderer", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		b := NewBackups(BackupsInput{Generations: 2})
+		fr, err := NewFileRenderer(FileRendererInput{Path: path, Backup: b.Backup})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []string{"good", "bad"} {
+			if _, err := fr.Render([]byte(c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := b.Rollback(path); err != nil {
+			t.Fatal(err)
+		}
+		check(t, []string{"good"}, contents(t, path))
+	})
+
+	t.Run("not-exists", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		b := NewBackups(BackupsInput{})
+		b.Backup(path)
+		list, err := b.List(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 0 {
+			t.Errorf("expected no backups, got %v", list)
+		}
+	})
+}
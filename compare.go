@@ -0,0 +1,51 @@
+package hcat
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// CompareFunc defines the function type passed in to compare the existing
+// contents with the newly rendered ones. It returns true if they are
+// equivalent, in which case the rendered contents are not written.
+type CompareFunc func(existing, rendered []byte) bool
+
+// NormalizeFunc transforms contents before they are compared, see
+// CompareNormalized.
+type NormalizeFunc func([]byte) []byte
+
+// CompareNormalized returns a CompareFunc that compares the contents after
+// passing each through the normalize functions, in order.
+//
+//	CompareNormalized(IgnoreLines(regexp.MustCompile(`^# generated at`)),
+//		NormalizeWhitespace)
+func CompareNormalized(normalize ...NormalizeFunc) CompareFunc {
+	return func(existing, rendered []byte) bool {
+		for _, fn := range normalize {
+			existing, rendered = fn(existing), fn(rendered)
+		}
+		return bytes.Equal(existing, rendered)
+	}
+}
+
+// NormalizeWhitespace replaces each run of whitespace with a single space
+// and trims any leading and trailing whitespace, so contents differing only
+// in whitespace (including line breaks) compare equal.
+func NormalizeWhitespace(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), []byte(" "))
+}
+
+// IgnoreLines returns a NormalizeFunc that removes the lines matching the
+// regular expression, eg. a generated timestamp comment.
+func IgnoreLines(re *regexp.Regexp) NormalizeFunc {
+	return func(b []byte) []byte {
+		lines := bytes.SplitAfter(b, []byte("\n"))
+		result := make([]byte, 0, len(b))
+		for _, line := range lines {
+			if !re.Match(bytes.TrimSuffix(line, []byte("\n"))) {
+				result = append(result, line...)
+			}
+		}
+		return result
+	}
+}
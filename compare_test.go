@@ -0,0 +1,96 @@
+package hcat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestCompareNormalized(t *testing.T) {
+	timestamp := IgnoreLines(regexp.MustCompile(`^# generated at `))
+	cases := []struct {
+		name       string
+		compare    CompareFunc
+		existing   string
+		rendered   string
+		equivalent bool
+	}{
+		{"none-same", CompareNormalized(), "a b", "a b", true},
+		{"none-diff", CompareNormalized(), "a b", "a  b", false},
+		{"whitespace", CompareNormalized(NormalizeWhitespace),
+			"a  b\n\tc\n", "a b c", true},
+		{"whitespace-diff", CompareNormalized(NormalizeWhitespace),
+			"a b c", "a bc", false},
+		{"ignore-lines", CompareNormalized(timestamp),
+			"# generated at 10:00\nfoo\n", "# generated at 11:00\nfoo\n", true},
+		{"ignore-lines-last", CompareNormalized(timestamp),
+			"foo\n# generated at 10:00", "foo\n# generated at 11:00", true},
+		{"ignore-lines-diff", CompareNormalized(timestamp),
+			"# generated at 10:00\nfoo\n", "# generated at 11:00\nbar\n", false},
+		{"ignore-lines-not-anchored", CompareNormalized(timestamp),
+			"x # generated at 10:00\n", "x # generated at 11:00\n", false},
+		{"combined", CompareNormalized(timestamp, NormalizeWhitespace),
+			"# generated at 10:00\nfoo  bar\n", "# generated at 11:00\nfoo bar", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			eq := tc.compare([]byte(tc.existing), []byte(tc.rendered))
+			if eq != tc.equivalent {
+				t.Errorf("expected %v, got %v", tc.equivalent, eq)
+			}
+		})
+	}
+}
+
+func TestRenderCompare(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+	path := filepath.Join(outDir, "out")
+	if err := ioutil.WriteFile(path, []byte("# 10:00\nfoo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := NewFileRenderer(FileRendererInput{
+		Path:    path,
+		Compare: CompareNormalized(IgnoreLines(regexp.MustCompile(`^#`))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl, err := NewTemplate(TemplateInput{Renderer: fr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		contents string
+		did      bool
+		exp      string
+		prev     string
+	}{
+		{"# 11:00\nfoo\n", false, "# 10:00\nfoo\n", "# 10:00\nfoo\n"},
+		{"# 12:00\nfoo\n", false, "# 10:00\nfoo\n", "# 10:00\nfoo\n"},
+		{"# 11:00\nbar\n", true, "# 11:00\nbar\n", "# 10:00\nfoo\n"},
+	} {
+		rr, err := tpl.Render([]byte(tc.contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender != tc.did || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		if act, _ := ioutil.ReadFile(path); string(act) != tc.exp {
+			t.Errorf("\nexp: %#v\nact: %#v", tc.exp, string(act))
+		}
+		// the hashes describe the contents on disk
+		if rr.Hash != contentHash([]byte(tc.exp)) ||
+			rr.PreviousHash != contentHash([]byte(tc.prev)) {
+			t.Errorf("bad hashes: %+v", rr)
+		}
+	}
+}
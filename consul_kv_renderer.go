@@ -0,0 +1,83 @@
+package hcat
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+// ErrWriteConflict is returned by the Consul KV and Vault KV Renderers when
+// the check-and-set write fails because the value was changed after it was
+// read.
+var ErrWriteConflict = errors.New("value changed during write")
+
+// ConsulKVRenderer renders the template text to a Consul KV key.
+type ConsulKVRenderer struct {
+	clients dep.Clients
+	key     string
+}
+
+// check for interface compliance
+var _ Renderer = (*ConsulKVRenderer)(nil)
+
+// ConsulKVRendererInput is the input structure for NewConsulKVRenderer.
+type ConsulKVRendererInput struct {
+	// Clients provides the Consul client, eg. the ClientSet (Looker)
+	Clients dep.Clients
+	// Key is the Consul KV key to write to
+	Key string
+}
+
+// NewConsulKVRenderer returns a new ConsulKVRenderer.
+func NewConsulKVRenderer(i ConsulKVRendererInput) *ConsulKVRenderer {
+	return &ConsulKVRenderer{
+		clients: i.Clients,
+		key:     strings.TrimPrefix(i.Key, "/"),
+	}
+}
+
+// Render writes the contents to the key if they differ from its value. The
+// write uses check-and-set on the ModifyIndex of the value read, so it won't
+// clobber a concurrent update, returning ErrWriteConflict instead.
+func (r *ConsulKVRenderer) Render(contents []byte) (RenderResult, error) {
+	if r.key == "" {
+		return RenderResult{}, errMissingDest
+	}
+	kv := r.clients.Consul().KV()
+
+	pair, _, err := kv.Get(r.key, nil)
+	if err != nil {
+		return RenderResult{}, errors.Wrap(err, "failed reading key")
+	}
+	if pair != nil && bytes.Equal(pair.Value, contents) {
+		return RenderResult{
+			DidRender:   false,
+			WouldRender: true,
+		}, nil
+	}
+
+	// a ModifyIndex of 0 only sets the key if it does not exist
+	var index uint64
+	if pair != nil {
+		index = pair.ModifyIndex
+	}
+	ok, _, err := kv.CAS(&api.KVPair{
+		Key:         r.key,
+		Value:       contents,
+		ModifyIndex: index,
+	}, nil)
+	switch {
+	case err != nil:
+		return RenderResult{}, errors.Wrap(err, "failed writing key")
+	case !ok:
+		return RenderResult{}, ErrWriteConflict
+	}
+
+	return RenderResult{
+		DidRender:   true,
+		WouldRender: true,
+	}, nil
+}
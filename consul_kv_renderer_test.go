@@ -0,0 +1,141 @@
+package hcat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeConsulKV is a minimal fake of the Consul KV API.
+type fakeConsulKV struct {
+	sync.Mutex
+	values  map[string][]byte
+	indexes map[string]uint64
+	index   uint64
+	writes  int
+	// beforeWrite is called before a write is applied, to simulate
+	// concurrent updates
+	beforeWrite func()
+}
+
+func newFakeConsulKV() (*fakeConsulKV, *httptest.Server) {
+	kv := &fakeConsulKV{
+		values:  make(map[string][]byte),
+		indexes: make(map[string]uint64),
+	}
+	return kv, httptest.NewServer(kv)
+}
+
+func (f *fakeConsulKV) set(key string, value []byte) {
+	f.index++
+	f.values[key] = value
+	f.indexes[key] = f.index
+}
+
+func (f *fakeConsulKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	w.Header().Set("X-Consul-LastContact", "0")
+	if r.URL.Path == "/v1/status/leader" {
+		w.Write([]byte(`"127.0.0.1:8300"`))
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+		v, ok := f.values[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{{
+			"Key": key, "Value": v, "ModifyIndex": f.indexes[key],
+		}})
+	case http.MethodPut:
+		if f.beforeWrite != nil {
+			f.beforeWrite()
+			f.beforeWrite = nil
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if cas := r.URL.Query().Get("cas"); cas != "" {
+			idx, _ := strconv.ParseUint(cas, 10, 64)
+			if idx != f.indexes[key] {
+				w.Write([]byte("false"))
+				return
+			}
+		}
+		f.writes++
+		f.set(key, body)
+		w.Write([]byte("true"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestConsulKVRenderer(t *testing.T) {
+	setup := func(t *testing.T) (*fakeConsulKV, *ConsulKVRenderer, func()) {
+		kv, srv := newFakeConsulKV()
+		clients := NewClientSet()
+		if err := clients.AddConsul(ConsulInput{Address: srv.URL}); err != nil {
+			t.Fatal(err)
+		}
+		r := NewConsulKVRenderer(ConsulKVRendererInput{
+			Clients: clients, Key: "/app/config"})
+		return kv, r, func() { clients.Stop(); srv.Close() }
+	}
+
+	t.Run("write", func(t *testing.T) {
+		kv, r, cleanup := setup(t)
+		defer cleanup()
+
+		for _, tc := range []struct {
+			contents string
+			did      bool
+		}{{"one", true}, {"one", false}, {"two", true}} {
+			rr, err := r.Render([]byte(tc.contents))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rr.DidRender != tc.did || !rr.WouldRender {
+				t.Fatalf("Bad render results; would: %v, did: %v",
+					rr.WouldRender, rr.DidRender)
+			}
+		}
+		if v := string(kv.values["app/config"]); v != "two" {
+			t.Errorf("bad value: %q", v)
+		}
+		if kv.writes != 2 {
+			t.Errorf("expected 2 writes, got %d", kv.writes)
+		}
+	})
+	t.Run("conflict", func(t *testing.T) {
+		kv, r, cleanup := setup(t)
+		defer cleanup()
+		kv.set("app/config", []byte("one"))
+		kv.beforeWrite = func() { kv.set("app/config", []byte("other")) }
+
+		rr, err := r.Render([]byte("two"))
+		if err != ErrWriteConflict {
+			t.Fatalf("expected %v, got %v", ErrWriteConflict, err)
+		}
+		if rr.DidRender || rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		if v := string(kv.values["app/config"]); v != "other" {
+			t.Errorf("value clobbered: %q", v)
+		}
+	})
+	t.Run("missing-key", func(t *testing.T) {
+		r := NewConsulKVRenderer(ConsulKVRendererInput{})
+		if _, err := r.Render([]byte("one")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -1,6 +1,10 @@
 package dep
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // ErrStopped is a special error that is returned when a dependency is
 // prematurely stopped, usually due to a configuration reload or a process
@@ -11,3 +15,15 @@ var ErrStopped = errors.New("dependency stopped")
 var ErrContinue = errors.New("dependency continue")
 
 var ErrLeaseExpired = errors.New("lease expired or is not renewable")
+
+// ACLTokenExpiredError is returned when the Consul ACL token in use has
+// expired. It is not retried as the token will not come back.
+type ACLTokenExpiredError struct {
+	AccessorID     string
+	ExpirationTime time.Time
+}
+
+func (e *ACLTokenExpiredError) Error() string {
+	return fmt.Sprintf("consul ACL token %s expired at %s", e.AccessorID,
+		e.ExpirationTime.Format(time.RFC3339))
+}
@@ -62,6 +62,153 @@ type HealthService struct {
 	Namespace           string
 }
 
+// PreparedQueryResult is the result of executing a prepared query in Consul.
+// Datacenter is the datacenter that answered the query and Failovers is the
+// number of remote datacenters that were tried to get there.
+type PreparedQueryResult struct {
+	Service    string
+	Namespace  string
+	Nodes      []*HealthService
+	Datacenter string
+	Failovers  int
+}
+
+// ConfigEntry is a configuration entry in Consul. The common fields are
+// pulled out and Config holds the whole entry as it was decoded from JSON so
+// kinds the client library does not know about can still be used.
+type ConfigEntry struct {
+	Kind        string
+	Name        string
+	Namespace   string
+	Meta        map[string]string
+	CreateIndex uint64
+	ModifyIndex uint64
+	Config      map[string]interface{}
+}
+
+// Decode returns the entry as the matching typed config entry from the Consul
+// API, e.g. *api.ServiceResolverConfigEntry for a service-resolver.
+func (c *ConfigEntry) Decode() (api.ConfigEntry, error) {
+	return api.DecodeConfigEntry(c.Config)
+}
+
+// Intention is a Connect intention in Consul, allowing or denying traffic
+// from a source service to a destination service.
+type Intention struct {
+	ID              string
+	Description     string
+	SourceNS        string
+	SourceName      string
+	DestinationNS   string
+	DestinationName string
+	SourceType      string
+	Action          string
+	Meta            map[string]string
+	Precedence      int
+	CreateIndex     uint64
+	ModifyIndex     uint64
+}
+
+// Session is a Consul session, as used for locks and leader election.
+type Session struct {
+	ID            string
+	Name          string
+	Node          string
+	Namespace     string
+	Behavior      string
+	TTL           string
+	LockDelay     time.Duration
+	Checks        []string
+	NodeChecks    []string
+	ServiceChecks []string
+	CreateIndex   uint64
+}
+
+// GatewayService is a service linked to an ingress or terminating gateway,
+// along with the settings the gateway uses for it.
+type GatewayService struct {
+	Gateway          string
+	GatewayNamespace string
+	Service          string
+	ServiceNamespace string
+	GatewayKind      string
+	Port             int
+	Protocol         string
+	Hosts            []string
+	CAFile           string
+	CertFile         string
+	KeyFile          string
+	SNI              string
+	FromWildcard     bool
+}
+
+// ACLToken is the Consul ACL token in use, without its secret.
+// ExpirationTime is zero for tokens that do not expire.
+type ACLToken struct {
+	AccessorID        string
+	Description       string
+	Policies          []ACLLink
+	Roles             []ACLLink
+	ServiceIdentities []string
+	Local             bool
+	ExpirationTime    time.Time
+	Namespace         string
+}
+
+// ACLLink is a reference to an ACL policy or role.
+type ACLLink struct {
+	ID   string
+	Name string
+}
+
+// HasPolicy returns true if the token is linked to the named policy.
+func (t *ACLToken) HasPolicy(name string) bool {
+	return hasLink(t.Policies, name)
+}
+
+// HasRole returns true if the token is linked to the named role.
+func (t *ACLToken) HasRole(name string) bool {
+	return hasLink(t.Roles, name)
+}
+
+func hasLink(links []ACLLink, name string) bool {
+	for _, l := range links {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentService is a service registered with the local Consul agent, which
+// may not be synced to the catalog yet.
+type AgentService struct {
+	ID        string
+	Kind      string
+	Service   string
+	Tags      ServiceTags
+	Meta      map[string]string
+	Port      int
+	Address   string
+	Weights   api.AgentWeights
+	Namespace string
+}
+
+// HealthCheck is a health check entry in Consul.
+type HealthCheck struct {
+	Node        string
+	CheckID     string
+	Name        string
+	Status      string
+	Notes       string
+	Output      string
+	ServiceID   string
+	ServiceName string
+	ServiceTags ServiceTags
+	Type        string
+	Namespace   string
+}
+
 // KeyPair is a simple Key-Value pair
 type KeyPair struct {
 	Path  string
@@ -24,9 +24,12 @@ var examples = []string{exampleServiceTemplate, exampleNodeTemplate}
 // ResolveEvent shows the template has fetched all values and completed, then
 // returns the output.
 func RenderExampleOnce(addr string) string {
-	tmpl := NewTemplate(TemplateInput{
+	tmpl, err := NewTemplate(TemplateInput{
 		Contents: exampleServiceTemplate,
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
 	clients := NewClientSet()
 	clients.AddConsul(ConsulInput{Address: addr})
 	w := NewWatcher(WatcherInput{
@@ -58,7 +61,11 @@ func RenderExampleOnce(addr string) string {
 func RenderMultipleOnce(addr string) string {
 	templates := make([]*Template, len(examples))
 	for i, egs := range examples {
-		templates[i] = NewTemplate(TemplateInput{Contents: egs})
+		tmpl, err := NewTemplate(TemplateInput{Contents: egs})
+		if err != nil {
+			log.Fatal(err)
+		}
+		templates[i] = tmpl
 	}
 	clients := NewClientSet()
 	clients.AddConsul(ConsulInput{Address: addr})
@@ -0,0 +1,189 @@
+package hcat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/hcat/dep"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Encrypter encrypts and decrypts rendered contents. Set it on the
+// FileRenderer to encrypt files at rest.
+type Encrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// DecryptFile reads the file at path, encrypted by a FileRenderer, and
+// returns its decrypted contents.
+func DecryptFile(path string, e Encrypter) ([]byte, error) {
+	ciphertext, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return e.Decrypt(ciphertext)
+}
+
+const (
+	secretboxKeySize   = 32
+	secretboxNonceSize = 24
+)
+
+// SecretboxEncrypter encrypts with NaCl secretbox (XSalsa20 and Poly1305)
+// using a local key. Encrypted contents are the random nonce followed by the
+// sealed box.
+type SecretboxEncrypter struct {
+	key [secretboxKeySize]byte
+}
+
+// check for interface compliance
+var _ Encrypter = (*SecretboxEncrypter)(nil)
+
+// NewSecretboxEncrypter returns a SecretboxEncrypter using the key in the
+// key file. The file holds the 32 byte key, either raw or base64 encoded.
+func NewSecretboxEncrypter(keyFile string) (*SecretboxEncrypter, error) {
+	b, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "secretbox: reading key file")
+	}
+	if len(b) != secretboxKeySize {
+		if b, err = base64.StdEncoding.DecodeString(
+			string(bytes.TrimSpace(b))); err != nil {
+			return nil, errors.Wrap(err, "secretbox: decoding key")
+		}
+	}
+	if len(b) != secretboxKeySize {
+		return nil, errors.Errorf("secretbox: key must be %d bytes, got %d",
+			secretboxKeySize, len(b))
+	}
+
+	var e SecretboxEncrypter
+	copy(e.key[:], b)
+	return &e, nil
+}
+
+// GenerateSecretboxKey returns a new random key, base64 encoded for use in a
+// SecretboxEncrypter key file.
+func GenerateSecretboxKey() (string, error) {
+	var key [secretboxKeySize]byte
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key[:]), nil
+}
+
+// Encrypt seals the plaintext with a random nonce.
+func (e *SecretboxEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	var nonce [secretboxNonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "secretbox: generating nonce")
+	}
+	return secretbox.Seal(nonce[:], plaintext, &nonce, &e.key), nil
+}
+
+// Decrypt opens the sealed ciphertext.
+func (e *SecretboxEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < secretboxNonceSize+secretbox.Overhead {
+		return nil, errors.New("secretbox: ciphertext too short")
+	}
+	var nonce [secretboxNonceSize]byte
+	copy(nonce[:], ciphertext)
+	plaintext, ok := secretbox.Open(nil, ciphertext[secretboxNonceSize:],
+		&nonce, &e.key)
+	if !ok {
+		return nil, errors.New("secretbox: decryption failed")
+	}
+	return plaintext, nil
+}
+
+// VaultTransitEncrypter encrypts using a Vault transit secrets engine key.
+// Encrypted contents are Vault's ciphertext string (vault:v1:...), so keys
+// can be rotated in Vault and older files still decrypted.
+type VaultTransitEncrypter struct {
+	clients dep.Clients
+	mount   string
+	key     string
+}
+
+// check for interface compliance
+var _ Encrypter = (*VaultTransitEncrypter)(nil)
+
+// VaultTransitEncrypterInput is the input structure for
+// NewVaultTransitEncrypter.
+type VaultTransitEncrypterInput struct {
+	// Clients provides the Vault client, eg. the ClientSet (Looker)
+	Clients dep.Clients
+	// Mount is the path the transit engine is mounted at (default "transit")
+	Mount string
+	// Key is the name of the transit key
+	Key string
+}
+
+// NewVaultTransitEncrypter returns a new VaultTransitEncrypter.
+func NewVaultTransitEncrypter(i VaultTransitEncrypterInput) *VaultTransitEncrypter {
+	mount := strings.Trim(i.Mount, "/")
+	if mount == "" {
+		mount = "transit"
+	}
+	return &VaultTransitEncrypter{
+		clients: i.Clients,
+		mount:   mount,
+		key:     i.Key,
+	}
+}
+
+// Encrypt encrypts the plaintext with the transit key.
+func (e *VaultTransitEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	secret, err := e.clients.Vault().Logical().Write(
+		path.Join(e.mount, "encrypt", e.key), map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "vault transit: encrypt")
+	}
+	ciphertext, err := transitField(secret, "ciphertext")
+	if err != nil {
+		return nil, errors.Wrap(err, "vault transit: encrypt")
+	}
+	return []byte(ciphertext), nil
+}
+
+// Decrypt decrypts the ciphertext with the transit key.
+func (e *VaultTransitEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	secret, err := e.clients.Vault().Logical().Write(
+		path.Join(e.mount, "decrypt", e.key), map[string]interface{}{
+			"ciphertext": string(bytes.TrimSpace(ciphertext)),
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "vault transit: decrypt")
+	}
+	plaintext, err := transitField(secret, "plaintext")
+	if err != nil {
+		return nil, errors.Wrap(err, "vault transit: decrypt")
+	}
+	b, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "vault transit: decrypt")
+	}
+	return b, nil
+}
+
+// transitField returns the string field from the transit response data.
+func transitField(secret *vaultapi.Secret, field string) (string, error) {
+	if secret == nil {
+		return "", errors.New("no response")
+	}
+	v, ok := secret.Data[field].(string)
+	if !ok {
+		return "", errors.Errorf("missing %s in response", field)
+	}
+	return v, nil
+}
@@ -0,0 +1,234 @@
+package hcat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSecretboxEncrypter(t *testing.T, dir string) *SecretboxEncrypter {
+	key, err := GenerateSecretboxKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewSecretboxEncrypter(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestSecretboxEncrypter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("round-trip", func(t *testing.T) {
+		e := testSecretboxEncrypter(t, dir)
+		ciphertext, err := e.Encrypt([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(ciphertext, []byte("secret")) {
+			t.Fatal("ciphertext contains plaintext")
+		}
+		again, _ := e.Encrypt([]byte("secret"))
+		if bytes.Equal(ciphertext, again) {
+			t.Error("nonce reused")
+		}
+		plaintext, err := e.Decrypt(ciphertext)
+		if err != nil || string(plaintext) != "secret" {
+			t.Fatalf("bad decryption: %q, %v", plaintext, err)
+		}
+
+		ciphertext[len(ciphertext)-1] ^= 1
+		if _, err := e.Decrypt(ciphertext); err == nil {
+			t.Error("expected error decrypting tampered ciphertext")
+		}
+		if _, err := e.Decrypt([]byte("short")); err == nil {
+			t.Error("expected error decrypting short ciphertext")
+		}
+	})
+	t.Run("raw-key", func(t *testing.T) {
+		keyFile := filepath.Join(dir, "raw")
+		if err := ioutil.WriteFile(keyFile, bytes.Repeat([]byte{1}, 32), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewSecretboxEncrypter(keyFile); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("bad-key", func(t *testing.T) {
+		keyFile := filepath.Join(dir, "bad")
+		short := base64.StdEncoding.EncodeToString([]byte("short"))
+		if err := ioutil.WriteFile(keyFile, []byte(short), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewSecretboxEncrypter(keyFile); err == nil {
+			t.Error("expected error for short key")
+		}
+		if _, err := NewSecretboxEncrypter(filepath.Join(dir, "missing")); err == nil {
+			t.Error("expected error for missing key file")
+		}
+	})
+}
+
+func TestRenderEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out")
+	// existing plaintext file is replaced
+	if err := ioutil.WriteFile(path, []byte("password"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := testSecretboxEncrypter(t, dir)
+	fr, err := NewFileRenderer(FileRendererInput{Path: path, Encrypter: e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		contents string
+		did      bool
+	}{{"password", true}, {"password", false}, {"new-password", true}} {
+		rr, err := fr.Render([]byte(tc.contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender != tc.did || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(raw, []byte("password")) {
+			t.Fatal("file contains plaintext")
+		}
+		plaintext, err := DecryptFile(path, e)
+		if err != nil || string(plaintext) != tc.contents {
+			t.Fatalf("bad decrypted file: %q, %v", plaintext, err)
+		}
+	}
+}
+
+func TestRenderEncryptedPlaintext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out")
+	e := testSecretboxEncrypter(t, dir)
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := NewFileRenderer(FileRendererInput{
+			Path:      path,
+			Encrypter: e,
+			Validate:  func(string) error { return nil },
+		})
+		if err != errValidateEncrypted {
+			t.Fatalf("expected %v, got %v", errValidateEncrypted, err)
+		}
+	})
+	t.Run("metadata", func(t *testing.T) {
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path: path, Encrypter: e, Metadata: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := NewTemplate(TemplateInput{Renderer: fr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []string{"password", "new-password"} {
+			if _, err := tpl.Render([]byte(c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var md renderMetadata
+		if err := json.Unmarshal(mustRead(t, path+metadataSuffix), &md); err != nil {
+			t.Fatal(err)
+		}
+		if md.Hash != "" || md.PreviousHash != "" || md.Path != path {
+			t.Errorf("bad metadata: %+v", md)
+		}
+	})
+}
+
+// fakeVaultTransit fakes the transit engine's encrypt/decrypt by prefixing
+// the base64 plaintext.
+func fakeVaultTransit() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		data := map[string]string{}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/app":
+			data["ciphertext"] = "vault:v1:" + body["plaintext"]
+		case "/v1/transit/decrypt/app":
+			if !strings.HasPrefix(body["ciphertext"], "vault:v1:") {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string][]string{
+					"errors": {"invalid ciphertext"}})
+				return
+			}
+			data["plaintext"] = strings.TrimPrefix(body["ciphertext"], "vault:v1:")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+func TestVaultTransitEncrypter(t *testing.T) {
+	srv := fakeVaultTransit()
+	defer srv.Close()
+	clients := NewClientSet()
+	defer clients.Stop()
+	if err := clients.AddVault(VaultInput{Address: srv.URL, Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewVaultTransitEncrypter(VaultTransitEncrypterInput{
+		Clients: clients, Key: "app"})
+	ciphertext, err := e.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(ciphertext), "vault:v1:") {
+		t.Fatalf("bad ciphertext: %q", ciphertext)
+	}
+	plaintext, err := e.Decrypt(append(ciphertext, '\n'))
+	if err != nil || string(plaintext) != "secret" {
+		t.Fatalf("bad decryption: %q, %v", plaintext, err)
+	}
+	if _, err := e.Decrypt([]byte("plaintext")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	bad := NewVaultTransitEncrypter(VaultTransitEncrypterInput{
+		Clients: clients, Mount: "other", Key: "app"})
+	if _, err := bad.Encrypt([]byte("secret")); err == nil {
+		t.Fatal("expected error")
+	}
+}
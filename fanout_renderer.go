@@ -0,0 +1,73 @@
+package hcat
+
+import (
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// FanoutRenderer renders the template text to several Renderers.
+type FanoutRenderer struct {
+	renderers []Renderer
+
+	mu sync.Mutex
+	// rendered is the DidRender of each renderer in the last render
+	rendered []bool
+}
+
+// check for interface compliance
+var _ Renderer = (*FanoutRenderer)(nil)
+var _ MetadataRenderer = (*FanoutRenderer)(nil)
+
+// NewFanoutRenderer returns a new FanoutRenderer that renders to each of the
+// renderers, in order.
+func NewFanoutRenderer(renderers ...Renderer) *FanoutRenderer {
+	return &FanoutRenderer{
+		renderers: renderers,
+		rendered:  make([]bool, len(renderers)),
+	}
+}
+
+// Render calls every renderer with the contents, even if some fail. The
+// result's DidRender and WouldRender are true if they are for any of the
+// renderers. Errors are returned together, in renderer order.
+func (r *FanoutRenderer) Render(contents []byte) (RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result RenderResult
+	var errs *multierror.Error
+	for i, rr := range r.renderers {
+		res, err := rr.Render(contents)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "renderer %d", i))
+		}
+		r.rendered[i] = res.DidRender
+		result.DidRender = result.DidRender || res.DidRender
+		result.WouldRender = result.WouldRender || res.WouldRender
+	}
+	return result, errs.ErrorOrNil()
+}
+
+// RenderMetadata passes the render's metadata on to the renderers that record
+// it (see MetadataRenderer), with DidRender set for each as it was in the
+// last render. Errors are returned together, in renderer order.
+func (r *FanoutRenderer) RenderMetadata(rr RenderResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs *multierror.Error
+	for i, renderer := range r.renderers {
+		mr, ok := renderer.(MetadataRenderer)
+		if !ok {
+			continue
+		}
+		res := rr
+		res.DidRender = r.rendered[i]
+		if err := mr.RenderMetadata(res); err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "renderer %d", i))
+		}
+	}
+	return errs.ErrorOrNil()
+}
@@ -0,0 +1,102 @@
+package hcat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFanoutRenderer(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		var b bytes.Buffer
+		m := NewMemoryRenderer(MemoryRendererInput{})
+		r := NewFanoutRenderer(NewWriterRenderer(WriterRendererInput{Writer: &b}), m)
+
+		rr, err := r.Render([]byte("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		if last, _ := m.Last(); b.String() != "foo" || string(last.Contents) != "foo" {
+			t.Fatalf("not rendered to all: %q, %q", b.String(), last.Contents)
+		}
+
+		rr, err = r.Render([]byte("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		m := NewMemoryRenderer(MemoryRendererInput{})
+		r := NewFanoutRenderer(
+			NewWriterRenderer(WriterRendererInput{Writer: errWriter{}}),
+			m,
+			NewWriterRenderer(WriterRendererInput{Writer: errWriter{}}),
+		)
+		rr, err := r.Render([]byte("foo"))
+		switch {
+		case err == nil:
+			t.Fatal("expected error")
+		case !strings.Contains(err.Error(), "renderer 0") ||
+			!strings.Contains(err.Error(), "renderer 2"):
+			t.Fatalf("bad error: %v", err)
+		}
+		if !rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		if _, ok := m.Last(); !ok {
+			t.Fatal("renderer after error not called")
+		}
+	})
+	t.Run("metadata", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		fr, err := NewFileRenderer(FileRendererInput{Path: path, Metadata: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := NewTemplate(TemplateInput{
+			Contents: "foo",
+			Renderer: NewFanoutRenderer(
+				NewMemoryRenderer(MemoryRendererInput{}), fr),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr, err := tpl.Render([]byte("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var md renderMetadata
+		if err := json.Unmarshal(mustRead(t, path+metadataSuffix), &md); err != nil {
+			t.Fatal(err)
+		}
+		if md.Path != path || md.Hash != rr.Hash || md.Size != 3 {
+			t.Errorf("bad metadata: %+v", md)
+		}
+	})
+	t.Run("none", func(t *testing.T) {
+		rr, err := NewFanoutRenderer().Render([]byte("foo"))
+		if err != nil || rr.DidRender || rr.WouldRender {
+			t.Fatalf("bad result: %v, %v", rr, err)
+		}
+	})
+}
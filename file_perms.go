@@ -3,7 +3,10 @@
 package hcat
 
 import (
+	"fmt"
 	"os"
+	"os/user"
+	"strconv"
 	"syscall"
 )
 
@@ -20,3 +23,33 @@ func preserveFilePermissions(path string, fileInfo os.FileInfo) error {
 
 	return nil
 }
+
+// lookupOwner returns the uid and gid for the user and group, given by name
+// or numeric id. An empty user or group returns -1 for that id, which leaves
+// it unchanged when passed to os.Chown.
+func lookupOwner(userName, groupName string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if userName != "" {
+		if uid, err = strconv.Atoi(userName); err != nil {
+			u, err := user.Lookup(userName)
+			if err != nil {
+				return -1, -1, fmt.Errorf("file owner: %s", err)
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return -1, -1, fmt.Errorf("file owner: bad uid %q", u.Uid)
+			}
+		}
+	}
+	if groupName != "" {
+		if gid, err = strconv.Atoi(groupName); err != nil {
+			g, err := user.LookupGroup(groupName)
+			if err != nil {
+				return -1, -1, fmt.Errorf("file group: %s", err)
+			}
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return -1, -1, fmt.Errorf("file group: bad gid %q", g.Gid)
+			}
+		}
+	}
+	return uid, gid, nil
+}
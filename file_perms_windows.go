@@ -2,8 +2,15 @@
 
 package hcat
 
-import "os"
+import (
+	"errors"
+	"os"
+)
 
 func preserveFilePermissions(path string, fileInfo os.FileInfo) error {
 	return nil
 }
+
+func lookupOwner(userName, groupName string) (uid, gid int, err error) {
+	return -1, -1, errors.New("file owner: not supported on windows")
+}
@@ -0,0 +1,77 @@
+//+build linux
+
+package hcat
+
+import (
+	"bytes"
+	"fmt"
+	"syscall"
+)
+
+const selinuxXattr = "security.selinux"
+
+// copyXattrs copies all the extended attributes of src to dst.
+func copyXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setxattr(dst, name, value, 0); err != nil {
+			return fmt.Errorf("xattr: set %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// setSELinuxContext sets the SELinux security context label of path.
+func setSELinuxContext(path, context string) error {
+	err := syscall.Setxattr(path, selinuxXattr, []byte(context), 0)
+	if err != nil {
+		return fmt.Errorf("selinux: set context %q: %s", context, err)
+	}
+	return nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err != nil {
+		if err == syscall.ENOTSUP {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("xattr: list: %s", err)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	size, err = syscall.Listxattr(path, buf)
+	if err != nil {
+		return nil, fmt.Errorf("xattr: list: %s", err)
+	}
+
+	var names []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	size, err := syscall.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("xattr: get %s: %s", name, err)
+	}
+	buf := make([]byte, size)
+	size, err = syscall.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, fmt.Errorf("xattr: get %s: %s", name, err)
+	}
+	return buf[:size], nil
+}
@@ -0,0 +1,67 @@
+//+build linux
+
+package hcat
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestAtomicWriteXattrs(t *testing.T) {
+	t.Run("preserve_xattrs", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+
+		file := filepath.Join(outDir, "file")
+		if err := ioutil.WriteFile(file, []byte("before"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err = syscall.Setxattr(file, "user.hcat", []byte("test"), 0)
+		if err != nil {
+			t.Skip("xattrs not supported:", err)
+		}
+
+		attrs := fileAttrs{preserveXattrs: true}
+		if err := atomicWrite(file, []byte("after"), attrs); err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := getXattr(file, "user.hcat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != "test" {
+			t.Errorf("expected %q to be %q", value, "test")
+		}
+	})
+
+	t.Run("selinux_context", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+
+		file := filepath.Join(outDir, "file")
+		context := "system_u:object_r:etc_t:s0"
+		attrs := fileAttrs{selinuxContext: context}
+		if err := atomicWrite(file, nil, attrs); err != nil {
+			t.Skip("selinux not supported:", err)
+		}
+
+		value, err := getXattr(file, selinuxXattr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bytes.TrimRight(value, "\x00")) != context {
+			t.Errorf("expected %q to be %q", value, context)
+		}
+	})
+}
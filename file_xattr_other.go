@@ -0,0 +1,14 @@
+//+build !linux
+
+package hcat
+
+import "errors"
+
+// copyXattrs is a no-op where extended attributes aren't supported.
+func copyXattrs(src, dst string) error {
+	return nil
+}
+
+func setSELinuxContext(path, context string) error {
+	return errors.New("selinux: only supported on linux")
+}
@@ -0,0 +1,345 @@
+package hcat
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"text/template"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// HCLTemplate is a Templater that uses HCL2 template syntax instead of
+// text/template. It has access to the same dependency functions as the
+// Template, called with HCL function syntax.
+//
+//	%{ for s in service("web") }server ${s.Address}:${s.Port}
+//	%{ endfor }
+//
+// Values returned by the functions are converted to HCL values using their
+// JSON representation, so fields are accessed by their (JSON) names.
+type HCLTemplate struct {
+	// contents is the string contents for the template.
+	contents string
+
+	// expr is the parsed template expression.
+	expr hclsyntax.Expression
+
+	// dirty indicates that the template's data has been updated and it
+	// needs to be re-rendered
+	dirty drainableChan
+
+	// name is the explicit identifier given on creation. If empty the hexMD5
+	// of the contents is used.
+	name string
+
+	// hexMD5 stores the hex version of the MD5
+	hexMD5 string
+
+	// funcMapMerge a map of functions that add-to or override those used
+	// when executing the template.
+	funcMapMerge template.FuncMap
+
+	// Renderer is the default renderer used for this template
+	renderer Renderer
+
+	// variables are the values available by name in the template.
+	varsLock  sync.RWMutex
+	variables map[string]interface{}
+
+	// waker is the watcher last used to execute the template, see
+	// Template.waker.
+	waker waker
+
+	// renders tracks the dependencies and hashes for the RenderResult
+	renders renderState
+}
+
+// check for interface compliance
+var _ Templater = (*HCLTemplate)(nil)
+var _ Notifier = (*HCLTemplate)(nil)
+
+// HCLTemplateInput is used as input when creating the HCL template.
+type HCLTemplateInput struct {
+	// Name is the identifier for the template, see TemplateInput.Name.
+	Name string
+
+	// Contents are the raw HCL template contents.
+	Contents string
+
+	// FuncMapMerge a map of functions that add-to or override those used when
+	// executing the template. It supports the same special case for functions
+	// requiring the Recaller as TemplateInput.FuncMapMerge. Function arguments
+	// and return values are converted to and from HCL values via JSON.
+	FuncMapMerge template.FuncMap
+
+	// Variables are values made available by name in the template.
+	// Use SetVariables to update them.
+	Variables map[string]interface{}
+
+	// Renderer is the default renderer used for this template
+	Renderer Renderer
+}
+
+// NewHCLTemplate creates and parses a new HCL template. Any errors parsing
+// the template or setting up its functions are returned.
+func NewHCLTemplate(i HCLTemplateInput) (*HCLTemplate, error) {
+	var t HCLTemplate
+	t.name = i.Name
+	t.contents = i.Contents
+	t.funcMapMerge = i.FuncMapMerge
+	t.renderer = i.Renderer
+	t.variables = i.Variables
+	t.dirty = make(drainableChan, 1)
+	t.Notify(nil) // prime template as needing to be run
+
+	// Compute the MD5, encode as hex
+	hash := md5.Sum([]byte(t.contents))
+	t.hexMD5 = hex.EncodeToString(hash[:])
+
+	expr, diags := hclsyntax.ParseTemplate(
+		[]byte(t.contents), t.ID(), hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrap(diags, "parse")
+	}
+	t.expr = expr
+
+	// Validate the functions can be converted to HCL functions. They are
+	// re-bound to the recaller for each execution.
+	if _, err := hclFunctions(funcMap(&funcMapInput{
+		funcMapMerge: t.funcMapMerge,
+	})); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// ID returns the identifier for this template. It is the name given on
+// creation or, if no name was given, the MD5 hash of the contents.
+func (t *HCLTemplate) ID() string {
+	if t.name != "" {
+		return t.name
+	}
+	return t.hexMD5
+}
+
+// Notify template that a dependency it relies on has been updated.
+func (t *HCLTemplate) Notify(dep.Dependency) {
+	select {
+	case t.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// Check and clear dirty flag
+func (t *HCLTemplate) isDirty() bool {
+	select {
+	case <-t.dirty:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetVariables replaces the variables available in the template and marks
+// the template as needing to be re-rendered, like a dependency update.
+// Like Template.SetData it wakes up the Watcher the template was executed with.
+func (t *HCLTemplate) SetVariables(vars map[string]interface{}) {
+	t.varsLock.Lock()
+	t.variables = vars
+	wk := t.waker
+	t.varsLock.Unlock()
+	t.Notify(nil)
+	if wk != nil {
+		wk.Wake(t.ID())
+	}
+}
+
+// Render calls the stored Renderer with the passed content, see
+// Template.Render.
+func (t *HCLTemplate) Render(content []byte) (RenderResult, error) {
+	return t.renders.render(t.renderer, content)
+}
+
+// Execute evaluates this template in the provided context.
+func (t *HCLTemplate) Execute(w Watcherer) ([]byte, error) {
+	if wk, ok := w.(waker); ok {
+		t.varsLock.Lock()
+		t.waker = wk
+		t.varsLock.Unlock()
+	}
+	if !t.isDirty() {
+		return nil, ErrNoNewValues
+	}
+
+	deps := newDepRecorder(w.Recaller(t))
+	funcs, err := hclFunctions(funcMap(&funcMapInput{
+		recaller:     deps.recall,
+		funcMapMerge: t.funcMapMerge,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	vars, err := t.ctyVariables()
+	if err != nil {
+		return nil, errors.Wrap(err, "variables")
+	}
+
+	val, diags := t.expr.Value(&hcl.EvalContext{
+		Variables: vars,
+		Functions: funcs,
+	})
+
+	// Checks if all values in use have been fetched before looking at any
+	// evaluation errors, as missing values (eg. null objects) can cause them.
+	// Also cleans out data no longer used by this template.
+	if !w.Complete(t) {
+		return nil, ErrMissingValues
+	}
+
+	if diags.HasErrors() {
+		return nil, errors.Wrap(diags, "execute")
+	}
+	t.renders.setDeps(deps.list())
+
+	val, err = convert.Convert(val, cty.String)
+	switch {
+	case err != nil:
+		return nil, errors.Wrap(err, "execute")
+	case val.IsNull() || !val.IsWhollyKnown():
+		return nil, errors.New("execute: template result is null or unknown")
+	}
+
+	return []byte(val.AsString()), nil
+}
+
+// ctyVariables converts the template's variables to HCL values.
+func (t *HCLTemplate) ctyVariables() (map[string]cty.Value, error) {
+	t.varsLock.RLock()
+	defer t.varsLock.RUnlock()
+
+	vars := make(map[string]cty.Value, len(t.variables))
+	for k, v := range t.variables {
+		val, err := toCtyValue(v)
+		if err != nil {
+			return nil, errors.Wrap(err, k)
+		}
+		vars[k] = val
+	}
+	return vars, nil
+}
+
+// hclFunctions converts the template functions to HCL functions.
+func hclFunctions(fm template.FuncMap) (map[string]function.Function, error) {
+	r := make(map[string]function.Function, len(fm))
+	for k, v := range fm {
+		f, err := hclFunction(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "function %q", k)
+		}
+		r[k] = f
+	}
+	return r, nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// hclFunction wraps a text/template style function, returning a value and an
+// optional error, as an HCL function. Arguments and the returned value are
+// converted between HCL and Go values using their JSON representation.
+func hclFunction(f interface{}) (function.Function, error) {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return function.Function{}, fmt.Errorf("not a function: %T", f)
+	}
+	switch {
+	case ft.NumOut() == 1:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		return function.Function{}, fmt.Errorf(
+			"functions must return a value and an optional error: %s", ft)
+	}
+
+	numParams := ft.NumIn()
+	if ft.IsVariadic() {
+		numParams--
+	}
+
+	var spec function.Spec
+	for i := 0; i < numParams; i++ {
+		spec.Params = append(spec.Params, function.Parameter{
+			Name: fmt.Sprintf("arg%d", i),
+			Type: cty.DynamicPseudoType,
+		})
+	}
+	if ft.IsVariadic() {
+		spec.VarParam = &function.Parameter{
+			Name: "args",
+			Type: cty.DynamicPseudoType,
+		}
+	}
+	spec.Type = function.StaticReturnType(cty.DynamicPseudoType)
+	spec.Impl = func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var t reflect.Type
+			if i < numParams {
+				t = ft.In(i)
+			} else {
+				t = ft.In(numParams).Elem()
+			}
+			v, err := fromCtyValue(arg, t)
+			if err != nil {
+				return cty.NilVal, function.NewArgError(i, err)
+			}
+			in[i] = v
+		}
+
+		out := fv.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return cty.NilVal, out[1].Interface().(error)
+		}
+		return toCtyValue(out[0].Interface())
+	}
+
+	return function.New(&spec), nil
+}
+
+// toCtyValue converts a Go value to an HCL value via its JSON representation.
+func toCtyValue(v interface{}) (cty.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	t, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, t)
+}
+
+// fromCtyValue converts an HCL value to a Go value of the given type via its
+// JSON representation.
+func fromCtyValue(v cty.Value, t reflect.Type) (reflect.Value, error) {
+	b, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	rv := reflect.New(t)
+	if err := json.Unmarshal(b, rv.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return rv.Elem(), nil
+}
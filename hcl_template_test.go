@@ -0,0 +1,272 @@
+package hcat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"text/template"
+
+	"github.com/hashicorp/hcat/dep"
+	idep "github.com/hashicorp/hcat/internal/dependency"
+)
+
+func TestNewHCLTemplate(t *testing.T) {
+	t.Run("parse-error", func(t *testing.T) {
+		_, err := NewHCLTemplate(HCLTemplateInput{
+			Name: "bad", Contents: "${key(}"})
+		if err == nil {
+			t.Fatal("expected parse error")
+		}
+	})
+	t.Run("bad-function", func(t *testing.T) {
+		_, err := NewHCLTemplate(HCLTemplateInput{
+			Contents:     "test",
+			FuncMapMerge: template.FuncMap{"bad": "not a function"},
+		})
+		if err == nil {
+			t.Fatal("expected function error")
+		}
+	})
+	t.Run("id", func(t *testing.T) {
+		a, err := NewHCLTemplate(HCLTemplateInput{Contents: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.ID() != "098f6bcd4621d373cade4e832627b4f6" {
+			t.Errorf("bad id: %q", a.ID())
+		}
+		b, err := NewHCLTemplate(HCLTemplateInput{Name: "b", Contents: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.ID() != "b" {
+			t.Errorf("bad id: %q", b.ID())
+		}
+	})
+}
+
+func TestHCLTemplate_Execute(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		ti   HCLTemplateInput
+		i    *Store
+		e    string
+		err  bool
+	}{
+		{
+			"plain",
+			HCLTemplateInput{
+				Contents: `test`,
+			},
+			NewStore(),
+			"test",
+			false,
+		},
+		{
+			"bad_func",
+			HCLTemplateInput{
+				Contents: `${bad_func()}`,
+			},
+			NewStore(),
+			"",
+			true,
+		},
+		{
+			"variables",
+			HCLTemplateInput{
+				Contents: `%{ if flags.canary }${name}-canary%{ endif }`,
+				Variables: map[string]interface{}{
+					"name":  "web",
+					"flags": map[string]bool{"canary": true},
+				},
+			},
+			NewStore(),
+			"web-canary",
+			false,
+		},
+		{
+			"func_key",
+			HCLTemplateInput{
+				Contents: `${key("key")}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewKVGetQuery("key")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), "5")
+				return st
+			}(),
+			"5",
+			false,
+		},
+		{
+			"func_keyOrDefault",
+			HCLTemplateInput{
+				Contents: `${keyOrDefault("no_key", "200")}`,
+			},
+			NewStore(),
+			"200",
+			false,
+		},
+		{
+			"func_service",
+			HCLTemplateInput{
+				Contents: `%{ for s in service("web") }${s.Node}=${s.Address}:${s.Port} %{ endfor }`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewHealthServiceQuery("web")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.HealthService{
+					{Node: "node1", Address: "1.2.3.4", Port: 80},
+					{Node: "node2", Address: "5.6.7.8", Port: 8080},
+				})
+				return st
+			}(),
+			"node1=1.2.3.4:80 node2=5.6.7.8:8080 ",
+			false,
+		},
+		{
+			"func_service_tag_filter",
+			HCLTemplateInput{
+				Contents: `${length(service("prod.web", "any"))}`,
+				FuncMapMerge: template.FuncMap{
+					"length": func(l []interface{}) int { return len(l) },
+				},
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewHealthServiceQuery("prod.web|any")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.HealthService{
+					{Node: "node1"}, {Node: "node2"},
+				})
+				return st
+			}(),
+			"2",
+			false,
+		},
+		{
+			"func_secret",
+			HCLTemplateInput{
+				Contents: `${secret("secret/foo").Data.zip}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewVaultReadQuery("secret/foo")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), &dep.Secret{
+					Data: map[string]interface{}{"zip": "zap"},
+				})
+				return st
+			}(),
+			"zap",
+			false,
+		},
+		{
+			"func_merge_recaller",
+			HCLTemplateInput{
+				Contents:     `${echo("foo")}`,
+				FuncMapMerge: template.FuncMap{"echo": echoFunc},
+			},
+			func() *Store {
+				st := NewStore()
+				st.Save((&idep.FakeDep{Name: "foo"}).String(), "foo")
+				return st
+			}(),
+			"foo",
+			false,
+		},
+		{
+			"func_error",
+			HCLTemplateInput{
+				Contents:     `${deny()}`,
+				FuncMapMerge: template.FuncMap{"deny": DenyFunc},
+			},
+			NewStore(),
+			"",
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			tpl, err := NewHCLTemplate(tc.ti)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a, err := tpl.Execute(fakeWatcher{tc.i})
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+			if !bytes.Equal([]byte(tc.e), a) {
+				t.Errorf("\nexp: %#v\nact: %#v", tc.e, string(a))
+			}
+		})
+	}
+}
+
+func TestHCLTemplate_Resolver(t *testing.T) {
+	t.Run("missing-then-complete", func(t *testing.T) {
+		rv := NewResolver()
+		w := blindWatcher(t)
+		defer w.Stop()
+		tpl, err := NewHCLTemplate(HCLTemplateInput{
+			Contents:     `%{ for w in words("foo", "bar") }${echo(w)}%{ endfor }`,
+			FuncMapMerge: template.FuncMap{"echo": echoFunc, "words": wordListFunc},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			r, err := rv.Run(tpl, w)
+			if err != nil {
+				t.Fatal("Run() error:", err)
+			}
+			if r.Complete {
+				if string(r.Contents) != "foobar" {
+					t.Fatal("Wrong contents:", string(r.Contents))
+				}
+				return
+			}
+			if !r.missing {
+				t.Fatal("missing should be true")
+			}
+			w.Wait(context.Background())
+		}
+		t.Fatal("template never completed")
+	})
+	t.Run("set-variables", func(t *testing.T) {
+		tpl, err := NewHCLTemplate(HCLTemplateInput{
+			Contents:  `${region}`,
+			Variables: map[string]interface{}{"region": "us-east-1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := fakeWatcher{NewStore()}
+		if a, err := tpl.Execute(w); err != nil || string(a) != "us-east-1" {
+			t.Fatalf("bad output: %q, %v", a, err)
+		}
+		if _, err := tpl.Execute(w); err != ErrNoNewValues {
+			t.Fatalf("expected %v, got %v", ErrNoNewValues, err)
+		}
+		tpl.SetVariables(map[string]interface{}{"region": "eu-west-1"})
+		if a, err := tpl.Execute(w); err != nil || string(a) != "eu-west-1" {
+			t.Fatalf("bad output: %q, %v", a, err)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*ACLTokenSelfQuery)(nil)
+
+	// ACLTokenSelfQuerySleepTime is the amount of time to sleep between
+	// queries, since the endpoint does not support blocking queries.
+	ACLTokenSelfQuerySleepTime = 30 * time.Second
+)
+
+func init() {
+	gob.Register(&dep.ACLToken{})
+}
+
+// ACLTokenSelfQuery is the dependency to read the Consul ACL token in use.
+type ACLTokenSelfQuery struct {
+	isConsul
+	stopCh chan struct{}
+	opts   QueryOptions
+
+	// last is the last token read, used to tell when it expires.
+	last *dep.ACLToken
+}
+
+// NewACLTokenSelfQuery creates a new ACL token self dependency.
+func NewACLTokenSelfQuery() (*ACLTokenSelfQuery, error) {
+	return &ACLTokenSelfQuery{
+		stopCh: make(chan struct{}, 1),
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns an
+// ACLToken. Once the token expires a *dep.ACLTokenExpiredError is returned.
+func (d *ACLTokenSelfQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{})
+
+	// The endpoint does not support blocking queries, so poll once we have
+	// returned data, waking up early for the token to expire.
+	if opts.WaitIndex != 0 {
+		sleep := ACLTokenSelfQuerySleepTime
+		if d.expires() {
+			if until := time.Until(d.last.ExpirationTime); until < sleep {
+				sleep = until
+			}
+		}
+
+		select {
+		case <-d.stopCh:
+			return nil, nil, ErrStopped
+		case <-time.After(sleep):
+		}
+	}
+
+	if err := d.expired(); err != nil {
+		return nil, nil, err
+	}
+
+	opts.WaitIndex = 0
+	opts.WaitTime = 0
+	token, _, err := clients.Consul().ACL().TokenReadSelf(opts.ToConsulOpts())
+	if err != nil {
+		// Consul removes expired tokens, the read can fail right at expiry.
+		if err := d.expired(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	d.last = aclToken(token)
+	return respWithMetadata(d.last)
+}
+
+// expires returns true if the last token read has an expiration time.
+func (d *ACLTokenSelfQuery) expires() bool {
+	return d.last != nil && !d.last.ExpirationTime.IsZero()
+}
+
+// expired returns an error if the last token read has expired.
+func (d *ACLTokenSelfQuery) expired() error {
+	if d.expires() && !time.Now().Before(d.last.ExpirationTime) {
+		return &dep.ACLTokenExpiredError{
+			AccessorID:     d.last.AccessorID,
+			ExpirationTime: d.last.ExpirationTime,
+		}
+	}
+	return nil
+}
+
+// CanShare returns a boolean if this dependency is shareable. The token is
+// the client's own, so it is never shared.
+func (d *ACLTokenSelfQuery) CanShare() bool {
+	return false
+}
+
+// Stop halts the dependency's fetch function.
+func (d *ACLTokenSelfQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *ACLTokenSelfQuery) String() string {
+	return "acl.token.self"
+}
+
+func (d *ACLTokenSelfQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// aclToken converts the API token, leaving out the secret and sorting the
+// links by name.
+func aclToken(t *api.ACLToken) *dep.ACLToken {
+	token := &dep.ACLToken{
+		AccessorID:  t.AccessorID,
+		Description: t.Description,
+		Policies:    aclLinks(t.Policies),
+		Roles:       aclLinks(t.Roles),
+		Local:       t.Local,
+		Namespace:   t.Namespace,
+	}
+	if t.ExpirationTime != nil {
+		token.ExpirationTime = *t.ExpirationTime
+	}
+
+	token.ServiceIdentities = make([]string, 0, len(t.ServiceIdentities))
+	for _, si := range t.ServiceIdentities {
+		token.ServiceIdentities = append(token.ServiceIdentities, si.ServiceName)
+	}
+	sort.Strings(token.ServiceIdentities)
+
+	return token
+}
+
+func aclLinks(links []*api.ACLLink) []dep.ACLLink {
+	result := make([]dep.ACLLink, 0, len(links))
+	for _, l := range links {
+		result = append(result, dep.ACLLink{ID: l.ID, Name: l.Name})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,138 @@
+package dependency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACLTokenSelfQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("expired", func(t *testing.T) {
+		d, err := NewACLTokenSelfQuery()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := time.Now().Add(-time.Minute)
+		d.last = &dep.ACLToken{AccessorID: "abcd", ExpirationTime: exp}
+
+		_, _, err = d.Fetch(testClients)
+		if assert.IsType(t, &dep.ACLTokenExpiredError{}, err) {
+			assert.Equal(t, "abcd", err.(*dep.ACLTokenExpiredError).AccessorID)
+			assert.True(t, exp.Equal(
+				err.(*dep.ACLTokenExpiredError).ExpirationTime))
+		}
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		d, err := NewACLTokenSelfQuery()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.SetOptions(QueryOptions{WaitIndex: 1})
+		d.Stop()
+
+		_, _, err = d.Fetch(testClients)
+		assert.Equal(t, ErrStopped, err)
+	})
+}
+
+func TestACLTokenSelfQuery_CanShare(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewACLTokenSelfQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, d.CanShare())
+}
+
+func TestACLTokenSelfQuery_expired(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		last *dep.ACLToken
+		err  bool
+	}{
+		{
+			"no_token",
+			nil,
+			false,
+		},
+		{
+			"no_expiration",
+			&dep.ACLToken{AccessorID: "abcd"},
+			false,
+		},
+		{
+			"not_expired",
+			&dep.ACLToken{ExpirationTime: time.Now().Add(time.Hour)},
+			false,
+		},
+		{
+			"expired",
+			&dep.ACLToken{ExpirationTime: time.Now().Add(-time.Second)},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &ACLTokenSelfQuery{last: tc.last}
+			err := d.expired()
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestACLToken(t *testing.T) {
+	t.Parallel()
+
+	exp := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	act := aclToken(&api.ACLToken{
+		AccessorID:  "abcd",
+		SecretID:    "secret",
+		Description: "test token",
+		Policies: []*api.ACLTokenPolicyLink{
+			{ID: "2", Name: "writer"},
+			{ID: "1", Name: "reader"},
+		},
+		Roles: []*api.ACLTokenRoleLink{
+			{ID: "3", Name: "ops"},
+		},
+		ServiceIdentities: []*api.ACLServiceIdentity{
+			{ServiceName: "web"},
+			{ServiceName: "api"},
+		},
+		ExpirationTime: &exp,
+	})
+
+	assert.Equal(t, &dep.ACLToken{
+		AccessorID:        "abcd",
+		Description:       "test token",
+		Policies:          []dep.ACLLink{{ID: "1", Name: "reader"}, {ID: "2", Name: "writer"}},
+		Roles:             []dep.ACLLink{{ID: "3", Name: "ops"}},
+		ServiceIdentities: []string{"api", "web"},
+		ExpirationTime:    exp,
+	}, act)
+	assert.True(t, act.HasPolicy("writer"))
+	assert.False(t, act.HasPolicy("ops"))
+	assert.True(t, act.HasRole("ops"))
+}
+
+func TestACLTokenSelfQuery_String(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewACLTokenSelfQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "acl.token.self", d.String())
+}
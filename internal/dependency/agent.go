@@ -0,0 +1,219 @@
+package dependency
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*AgentServicesQuery)(nil)
+	_ isDependency = (*AgentChecksQuery)(nil)
+
+	// AgentQuerySleepTime is the amount of time to sleep between queries to
+	// the local agent, since its services and checks endpoints do not support
+	// blocking queries.
+	AgentQuerySleepTime = 5 * time.Second
+)
+
+func init() {
+	gob.Register([]*dep.AgentService{})
+}
+
+// AgentServicesQuery is the dependency to query the services registered with
+// the local agent.
+type AgentServicesQuery struct {
+	isConsul
+	stopCh chan struct{}
+	opts   QueryOptions
+	poller agentPoller
+}
+
+// NewAgentServicesQuery creates a new agent services dependency.
+func NewAgentServicesQuery() (*AgentServicesQuery, error) {
+	return &AgentServicesQuery{
+		stopCh: make(chan struct{}, 1),
+	}, nil
+}
+
+// Fetch queries the local agent defined by the given client and returns a
+// slice of AgentService objects sorted by ID. After the first call it polls
+// the agent until the services change.
+func (d *AgentServicesQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	return d.poller.poll(d.stopCh, d.opts, func() (interface{}, error) {
+		services, err := clients.Consul().Agent().Services()
+		if err != nil {
+			return nil, errors.Wrap(err, d.String())
+		}
+
+		list := make([]*dep.AgentService, 0, len(services))
+		for _, s := range services {
+			list = append(list, &dep.AgentService{
+				ID:        s.ID,
+				Kind:      string(s.Kind),
+				Service:   s.Service,
+				Tags:      dep.ServiceTags(deepCopyAndSortTags(s.Tags)),
+				Meta:      s.Meta,
+				Port:      s.Port,
+				Address:   s.Address,
+				Weights:   s.Weights,
+				Namespace: s.Namespace,
+			})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].ID < list[j].ID
+		})
+		return list, nil
+	})
+}
+
+// CanShare returns a boolean if this dependency is shareable. The services
+// are those of whichever agent the client talks to, so they aren't shared.
+func (d *AgentServicesQuery) CanShare() bool {
+	return false
+}
+
+// Stop halts the dependency's fetch function.
+func (d *AgentServicesQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *AgentServicesQuery) String() string {
+	return "agent.services"
+}
+
+func (d *AgentServicesQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// AgentChecksQuery is the dependency to query the checks registered with the
+// local agent.
+type AgentChecksQuery struct {
+	isConsul
+	stopCh chan struct{}
+	opts   QueryOptions
+	poller agentPoller
+}
+
+// NewAgentChecksQuery creates a new agent checks dependency.
+func NewAgentChecksQuery() (*AgentChecksQuery, error) {
+	return &AgentChecksQuery{
+		stopCh: make(chan struct{}, 1),
+	}, nil
+}
+
+// Fetch queries the local agent defined by the given client and returns a
+// slice of HealthCheck objects sorted by check ID. After the first call it
+// polls the agent until the checks change.
+func (d *AgentChecksQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	return d.poller.poll(d.stopCh, d.opts, func() (interface{}, error) {
+		checks, err := clients.Consul().Agent().Checks()
+		if err != nil {
+			return nil, errors.Wrap(err, d.String())
+		}
+
+		list := make([]*dep.HealthCheck, 0, len(checks))
+		for _, c := range checks {
+			list = append(list, &dep.HealthCheck{
+				Node:        c.Node,
+				CheckID:     c.CheckID,
+				Name:        c.Name,
+				Status:      c.Status,
+				Notes:       c.Notes,
+				Output:      c.Output,
+				ServiceID:   c.ServiceID,
+				ServiceName: c.ServiceName,
+				Type:        c.Type,
+				Namespace:   c.Namespace,
+			})
+		}
+		sort.Stable(ByNodeThenCheckID(list))
+		return list, nil
+	})
+}
+
+// CanShare returns a boolean if this dependency is shareable. The checks
+// are those of whichever agent the client talks to, so they aren't shared.
+func (d *AgentChecksQuery) CanShare() bool {
+	return false
+}
+
+// Stop halts the dependency's fetch function.
+func (d *AgentChecksQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *AgentChecksQuery) String() string {
+	return "agent.checks"
+}
+
+func (d *AgentChecksQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// agentPoller fakes blocking queries for the local agent endpoints, which
+// don't support them, by polling until the result changes.
+type agentPoller struct {
+	// hash is the hash of the last result returned.
+	hash string
+	// index is the index of the last result returned. It is a counter, not a
+	// time, so results returned within the same second have different
+	// indexes and aren't dropped by the view.
+	index uint64
+}
+
+// poll calls get and returns its result. On later calls, when a wait index is
+// set, it polls every AgentQuerySleepTime until the hash of the result
+// differs from the last one returned.
+func (p *agentPoller) poll(stopCh chan struct{}, opts QueryOptions,
+	get func() (interface{}, error),
+) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	for {
+		result, err := get()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hash, err := agentHash(result)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if opts.WaitIndex == 0 || hash != p.hash {
+			p.hash = hash
+			p.index++
+			return result, &dep.ResponseMetadata{LastIndex: p.index}, nil
+		}
+
+		select {
+		case <-stopCh:
+			return nil, nil, ErrStopped
+		case <-time.After(AgentQuerySleepTime):
+		}
+	}
+}
+
+// agentHash returns the hex encoded sha256 of the JSON encoded result.
+func agentHash(result interface{}) (string, error) {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", errors.Wrap(err, "agent: hashing result")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,133 @@
+package dependency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentServicesQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewAgentServicesQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act, _, err := d.Fetch(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found *dep.AgentService
+	for _, s := range act.([]*dep.AgentService) {
+		if s.ID == "service-meta" {
+			found = s
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "service-meta", found.Service)
+		assert.Equal(t, dep.ServiceTags([]string{"tag1"}), found.Tags)
+		assert.Equal(t, map[string]string{"meta1": "value1"}, found.Meta)
+	}
+	assert.NotEmpty(t, d.poller.hash)
+}
+
+func TestAgentChecksQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewAgentChecksQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act, _, err := d.Fetch(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, []*dep.HealthCheck{}, act)
+	assert.NotEmpty(t, d.poller.hash)
+}
+
+func TestAgentPoller(t *testing.T) {
+	sleep := AgentQuerySleepTime
+	AgentQuerySleepTime = time.Millisecond
+	defer func() { AgentQuerySleepTime = sleep }()
+
+	results := []string{"a", "a", "a", "b"}
+	calls := 0
+	get := func() (interface{}, error) {
+		r := results[calls]
+		calls++
+		return r, nil
+	}
+
+	stopCh := make(chan struct{}, 1)
+	var p agentPoller
+
+	t.Run("first", func(t *testing.T) {
+		act, rm, err := p.poll(stopCh, QueryOptions{}, get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "a", act)
+		assert.Equal(t, uint64(1), rm.LastIndex)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("waits_for_change", func(t *testing.T) {
+		act, rm, err := p.poll(stopCh, QueryOptions{WaitIndex: 1}, get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "b", act)
+		assert.Equal(t, uint64(2), rm.LastIndex)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		results = []string{"b", "b"}
+		calls = 0
+		AgentQuerySleepTime = time.Hour
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(stopCh)
+		}()
+		_, _, err := p.poll(stopCh, QueryOptions{WaitIndex: 2}, get)
+		assert.Equal(t, ErrStopped, err)
+	})
+}
+
+func TestAgentQuery_String(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewAgentServicesQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "agent.services", s.String())
+
+	c, err := NewAgentChecksQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "agent.checks", c.String())
+}
+
+func TestAgentQuery_CanShare(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewAgentServicesQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, s.CanShare())
+
+	c, err := NewAgentChecksQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, c.CanShare())
+}
@@ -0,0 +1,149 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*CatalogGatewayServicesQuery)(nil)
+
+	// CatalogGatewayServicesQueryRe is the regular expression to use.
+	CatalogGatewayServicesQueryRe = regexp.MustCompile(`\A` + serviceNameRe + dcRe + paramsRe + `\z`)
+)
+
+func init() {
+	gob.Register([]*dep.GatewayService{})
+}
+
+// CatalogGatewayServicesQuery is the representation of the services linked to
+// an ingress or terminating gateway in Consul.
+type CatalogGatewayServicesQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc     string
+	params queryParams
+	name   string
+	opts   QueryOptions
+}
+
+// NewCatalogGatewayServicesQuery parses a string into a gateway services
+// dependency. The format is gateway[@dc][?ns=namespace&partition=partition].
+func NewCatalogGatewayServicesQuery(s string) (*CatalogGatewayServicesQuery, error) {
+	if !CatalogGatewayServicesQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("catalog.gateway_services: invalid format: %q", s)
+	}
+
+	m := regexpMatch(CatalogGatewayServicesQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err == nil {
+		err = params.only()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("catalog.gateway_services: %s in %q", err, s)
+	}
+
+	return &CatalogGatewayServicesQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		params: params,
+		name:   m["name"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a
+// slice of GatewayService objects.
+func (d *CatalogGatewayServicesQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+	})
+
+	entries, qm, err := clients.Consul().Catalog().GatewayServices(d.name,
+		opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	list := make([]*dep.GatewayService, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, &dep.GatewayService{
+			Gateway:          e.Gateway.Name,
+			GatewayNamespace: e.Gateway.Namespace,
+			Service:          e.Service.Name,
+			ServiceNamespace: e.Service.Namespace,
+			GatewayKind:      string(e.GatewayKind),
+			Port:             e.Port,
+			Protocol:         e.Protocol,
+			Hosts:            e.Hosts,
+			CAFile:           e.CAFile,
+			CertFile:         e.CertFile,
+			KeyFile:          e.KeyFile,
+			SNI:              e.SNI,
+			FromWildcard:     e.FromWildcard,
+		})
+	}
+	sort.Stable(ByPortThenService(list))
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return list, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *CatalogGatewayServicesQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *CatalogGatewayServicesQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *CatalogGatewayServicesQuery) String() string {
+	name := d.name
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	name = name + d.params.String()
+	return fmt.Sprintf("catalog.gateway_services(%s)", name)
+}
+
+func (d *CatalogGatewayServicesQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// ByPortThenService is a sortable slice of GatewayService
+type ByPortThenService []*dep.GatewayService
+
+// Len, Swap, and Less are used to implement the sort.Sort interface.
+func (s ByPortThenService) Len() int      { return len(s) }
+func (s ByPortThenService) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByPortThenService) Less(i, j int) bool {
+	a, b := s[i], s[j]
+	switch {
+	case a.Port != b.Port:
+		return a.Port < b.Port
+	case a.ServiceNamespace != b.ServiceNamespace:
+		return a.ServiceNamespace < b.ServiceNamespace
+	}
+	return a.Service < b.Service
+}
@@ -0,0 +1,193 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCatalogGatewayServicesQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *CatalogGatewayServicesQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"dc_only",
+			"@dc1",
+			nil,
+			true,
+		},
+		{
+			"name",
+			"ingress-gateway",
+			&CatalogGatewayServicesQuery{
+				name: "ingress-gateway",
+			},
+			false,
+		},
+		{
+			"dc_ns",
+			"ingress-gateway@dc1?ns=team-a",
+			&CatalogGatewayServicesQuery{
+				name:   "ingress-gateway",
+				dc:     "dc1",
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
+		{
+			"partition",
+			"ingress-gateway?partition=eu",
+			&CatalogGatewayServicesQuery{
+				name:   "ingress-gateway",
+				params: queryParams{partition: "eu"},
+			},
+			false,
+		},
+		{
+			"node_meta",
+			"ingress-gateway?node-meta=rack:r1",
+			nil,
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewCatalogGatewayServicesQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestCatalogGatewayServicesQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	_, err := testClients.Consul().Raw().Write("/v1/config",
+		map[string]interface{}{
+			"Kind": "terminating-gateway",
+			"Name": "gateway-services-test",
+			"Services": []map[string]interface{}{
+				{"Name": "legacy-db", "CAFile": "/etc/ca.pem", "SNI": "db.internal"},
+				{"Name": "legacy-api"},
+			},
+		}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		i    string
+		exp  []*dep.GatewayService
+	}{
+		{
+			"terminating",
+			"gateway-services-test",
+			[]*dep.GatewayService{
+				{
+					Gateway:     "gateway-services-test",
+					Service:     "legacy-api",
+					GatewayKind: "terminating-gateway",
+				},
+				{
+					Gateway:     "gateway-services-test",
+					Service:     "legacy-db",
+					GatewayKind: "terminating-gateway",
+					CAFile:      "/etc/ca.pem",
+					SNI:         "db.internal",
+				},
+			},
+		},
+		{
+			"unknown",
+			"not-a-real-gateway",
+			[]*dep.GatewayService{},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewCatalogGatewayServicesQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestCatalogGatewayServicesQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"name",
+			"ingress",
+			"catalog.gateway_services(ingress)",
+		},
+		{
+			"dc_ns",
+			"ingress@dc1?ns=team-a",
+			"catalog.gateway_services(ingress@dc1?ns=team-a)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewCatalogGatewayServicesQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
+
+func TestByPortThenService(t *testing.T) {
+	t.Parallel()
+
+	list := []*dep.GatewayService{
+		{Service: "web", Port: 8080},
+		{Service: "api", Port: 9090},
+		{Service: "admin", Port: 8080},
+	}
+	sort.Stable(ByPortThenService(list))
+
+	names := []string{}
+	for _, s := range list {
+		names = append(names, s.Service)
+	}
+	assert.Equal(t, []string{"admin", "web", "api"}, names)
+}
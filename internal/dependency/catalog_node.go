@@ -15,7 +15,7 @@ var (
 	_ isDependency = (*CatalogNodeQuery)(nil)
 
 	// CatalogNodeQueryRe is the regular expression to use.
-	CatalogNodeQueryRe = regexp.MustCompile(`\A` + nodeNameRe + dcRe + `\z`)
+	CatalogNodeQueryRe = regexp.MustCompile(`\A` + nodeNameRe + dcRe + paramsRe + `\z`)
 )
 
 func init() {
@@ -28,9 +28,10 @@ type CatalogNodeQuery struct {
 	isConsul
 	stopCh chan struct{}
 
-	dc   string
-	name string
-	opts QueryOptions
+	dc     string
+	params queryParams
+	name   string
+	opts   QueryOptions
 }
 
 // NewCatalogNodeQuery parses the given string into a dependency. If the name is
@@ -41,8 +42,14 @@ func NewCatalogNodeQuery(s string) (*CatalogNodeQuery, error) {
 	}
 
 	m := regexpMatch(CatalogNodeQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("catalog.node: %s in %q", err, s)
+	}
+
 	return &CatalogNodeQuery{
 		dc:     m["dc"],
+		params: params,
 		name:   m["name"],
 		stopCh: make(chan struct{}, 1),
 	}, nil
@@ -59,6 +66,10 @@ func (d *CatalogNodeQuery) Fetch(clients dep.Clients) (interface{}, *dep.Respons
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
 	})
 
 	// Grab the name
@@ -135,6 +146,7 @@ func (d *CatalogNodeQuery) String() string {
 	if d.dc != "" {
 		name = name + "@" + d.dc
 	}
+	name = name + d.params.String()
 
 	if name == "" {
 		return "catalog.node"
@@ -15,7 +15,7 @@ var (
 	_ isDependency = (*CatalogNodesQuery)(nil)
 
 	// CatalogNodesQueryRe is the regular expression to use.
-	CatalogNodesQueryRe = regexp.MustCompile(`\A` + dcRe + nearRe + `\z`)
+	CatalogNodesQueryRe = regexp.MustCompile(`\A` + dcRe + nearRe + paramsRe + `\z`)
 )
 
 func init() {
@@ -27,9 +27,10 @@ type CatalogNodesQuery struct {
 	isConsul
 	stopCh chan struct{}
 
-	dc   string
-	near string
-	opts QueryOptions
+	dc     string
+	params queryParams
+	near   string
+	opts   QueryOptions
 }
 
 // NewCatalogNodesQuery parses the given string into a dependency. If the name is
@@ -40,8 +41,14 @@ func NewCatalogNodesQuery(s string) (*CatalogNodesQuery, error) {
 	}
 
 	m := regexpMatch(CatalogNodesQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("catalog.nodes: %s in %q", err, s)
+	}
+
 	return &CatalogNodesQuery{
 		dc:     m["dc"],
+		params: params,
 		near:   m["near"],
 		stopCh: make(chan struct{}, 1),
 	}, nil
@@ -58,6 +65,10 @@ func (d *CatalogNodesQuery) Fetch(clients dep.Clients) (interface{}, *dep.Respon
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
 		Near:       d.near,
 	})
 
@@ -111,6 +122,7 @@ func (d *CatalogNodesQuery) String() string {
 	if d.near != "" {
 		name = name + "~" + d.near
 	}
+	name = name + d.params.String()
 
 	if name == "" {
 		return "catalog.nodes"
@@ -15,7 +15,7 @@ var (
 	_ isDependency = (*CatalogServiceQuery)(nil)
 
 	// CatalogServiceQueryRe is the regular expression to use.
-	CatalogServiceQueryRe = regexp.MustCompile(`\A` + tagRe + serviceNameRe + dcRe + nearRe + `\z`)
+	CatalogServiceQueryRe = regexp.MustCompile(`\A` + tagRe + serviceNameRe + dcRe + nearRe + paramsRe + `\z`)
 )
 
 func init() {
@@ -45,11 +45,12 @@ type CatalogServiceQuery struct {
 	isConsul
 	stopCh chan struct{}
 
-	dc   string
-	name string
-	near string
-	tag  string
-	opts QueryOptions
+	dc     string
+	params queryParams
+	name   string
+	near   string
+	tag    string
+	opts   QueryOptions
 }
 
 // NewCatalogServiceQuery parses a string into a CatalogServiceQuery.
@@ -59,9 +60,15 @@ func NewCatalogServiceQuery(s string) (*CatalogServiceQuery, error) {
 	}
 
 	m := regexpMatch(CatalogServiceQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("catalog.service: %s in %q", err, s)
+	}
+
 	return &CatalogServiceQuery{
 		stopCh: make(chan struct{}, 1),
 		dc:     m["dc"],
+		params: params,
 		name:   m["name"],
 		near:   m["near"],
 		tag:    m["tag"],
@@ -79,6 +86,10 @@ func (d *CatalogServiceQuery) Fetch(clients dep.Clients) (interface{}, *dep.Resp
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
 		Near:       d.near,
 	})
 
@@ -144,6 +155,7 @@ func (d *CatalogServiceQuery) String() string {
 	if d.near != "" {
 		name = name + "~" + d.near
 	}
+	name = name + d.params.String()
 	return fmt.Sprintf("catalog.service(%s)", name)
 }
 
@@ -15,7 +15,7 @@ var (
 	_ isDependency = (*CatalogServicesQuery)(nil)
 
 	// CatalogServicesQueryRe is the regular expression to use for CatalogNodesQuery.
-	CatalogServicesQueryRe = regexp.MustCompile(`\A` + dcRe + `\z`)
+	CatalogServicesQueryRe = regexp.MustCompile(`\A` + dcRe + paramsRe + `\z`)
 )
 
 func init() {
@@ -28,8 +28,9 @@ type CatalogServicesQuery struct {
 	isConsul
 	stopCh chan struct{}
 
-	dc   string
-	opts QueryOptions
+	dc     string
+	params queryParams
+	opts   QueryOptions
 }
 
 // NewCatalogServicesQuery parses a string of the format @dc.
@@ -39,9 +40,15 @@ func NewCatalogServicesQuery(s string) (*CatalogServicesQuery, error) {
 	}
 
 	m := regexpMatch(CatalogServicesQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("catalog.services: %s in %q", err, s)
+	}
+
 	return &CatalogServicesQuery{
 		stopCh: make(chan struct{}, 1),
 		dc:     m["dc"],
+		params: params,
 	}, nil
 }
 
@@ -56,6 +63,10 @@ func (d *CatalogServicesQuery) Fetch(clients dep.Clients) (interface{}, *dep.Res
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
 	})
 
 	//log.Printf("[TRACE] %s: GET %s", d, &url.URL{
@@ -95,10 +106,16 @@ func (d *CatalogServicesQuery) CanShare() bool {
 
 // String returns the human-friendly version of this dependency.
 func (d *CatalogServicesQuery) String() string {
+	var name string
 	if d.dc != "" {
-		return fmt.Sprintf("catalog.services(@%s)", d.dc)
+		name = name + "@" + d.dc
+	}
+	name = name + d.params.String()
+
+	if name == "" {
+		return "catalog.services"
 	}
-	return "catalog.services"
+	return fmt.Sprintf("catalog.services(%s)", name)
 }
 
 // Stop halts the dependency's fetch function.
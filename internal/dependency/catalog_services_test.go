@@ -37,6 +37,23 @@ func TestNewCatalogServicesQuery(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"ns",
+			"?ns=team-a",
+			&CatalogServicesQuery{
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
+		{
+			"dc_ns",
+			"@dc1?ns=team-a",
+			&CatalogServicesQuery{
+				dc:     "dc1",
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
 	}
 
 	for i, tc := range cases {
@@ -81,6 +98,21 @@ func TestCatalogServicesQuery_Fetch(t *testing.T) {
 				},
 			},
 		},
+		{
+			"filter",
+			`?filter=ServiceName == "consul"`,
+			[]*dep.CatalogSnippet{
+				&dep.CatalogSnippet{
+					Name: "consul",
+					Tags: dep.ServiceTags([]string{}),
+				},
+			},
+		},
+		{
+			"node_meta",
+			"?node-meta=rack:not-a-real-rack",
+			nil,
+		},
 	}
 
 	for i, tc := range cases {
@@ -118,6 +150,11 @@ func TestCatalogServicesQuery_String(t *testing.T) {
 			"@dc1",
 			"catalog.services(@dc1)",
 		},
+		{
+			"datacenter_ns",
+			"@dc1?ns=team-a",
+			"catalog.services(@dc1?ns=team-a)",
+		},
 	}
 
 	for i, tc := range cases {
@@ -0,0 +1,240 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*ConfigEntryQuery)(nil)
+	_ isDependency = (*ConfigEntriesQuery)(nil)
+
+	// ConfigEntryQueryRe is the regular expression to use for a single config
+	// entry.
+	ConfigEntryQueryRe = regexp.MustCompile(`\A` + configKindRe + `/` + serviceNameRe + dcRe + `\z`)
+
+	// ConfigEntriesQueryRe is the regular expression to use for listing config
+	// entries.
+	ConfigEntriesQueryRe = regexp.MustCompile(`\A` + configKindRe + dcRe + `\z`)
+)
+
+const configKindRe = `(?P<kind>[a-z\-]+)`
+
+func init() {
+	gob.Register(&dep.ConfigEntry{})
+	gob.Register([]*dep.ConfigEntry{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// ConfigEntryQuery is the representation of a single config entry, such as a
+// service-resolver, in Consul.
+type ConfigEntryQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc   string
+	kind string
+	name string
+	opts QueryOptions
+}
+
+// NewConfigEntryQuery processes the string to build a config entry
+// dependency. The format is kind/name@dc.
+func NewConfigEntryQuery(s string) (*ConfigEntryQuery, error) {
+	if !ConfigEntryQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("config_entry: invalid format: %q", s)
+	}
+
+	m := regexpMatch(ConfigEntryQueryRe, s)
+	return &ConfigEntryQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		kind:   m["kind"],
+		name:   m["name"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a
+// ConfigEntry, or nil if the entry does not exist.
+func (d *ConfigEntryQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+	})
+
+	// Query the kind's list rather than the entry itself. A missing entry is
+	// a 404 whose index the API client drops, while the list carries the
+	// same index either way, so the blocking query works before the entry
+	// exists.
+	var raw []map[string]interface{}
+	qm, err := clients.Consul().Raw().Query(
+		"/v1/config/"+d.kind, &raw, opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	for _, r := range raw {
+		if name, _ := r["Name"].(string); name == d.name {
+			return configEntry(r), rm, nil
+		}
+	}
+	return nil, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *ConfigEntryQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *ConfigEntryQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *ConfigEntryQuery) String() string {
+	name := d.kind + "/" + d.name
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	return fmt.Sprintf("config_entry(%s)", name)
+}
+
+func (d *ConfigEntryQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// ConfigEntriesQuery is the representation of all config entries of a kind
+// in Consul.
+type ConfigEntriesQuery struct {
+	isConsul
+	isBlocking
+	stopCh chan struct{}
+
+	dc   string
+	kind string
+	opts QueryOptions
+}
+
+// NewConfigEntriesQuery processes the string to build a config entries
+// dependency. The format is kind@dc.
+func NewConfigEntriesQuery(s string) (*ConfigEntriesQuery, error) {
+	if !ConfigEntriesQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("config_entries: invalid format: %q", s)
+	}
+
+	m := regexpMatch(ConfigEntriesQueryRe, s)
+	return &ConfigEntriesQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		kind:   m["kind"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a
+// slice of ConfigEntry objects sorted by name.
+func (d *ConfigEntriesQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+	})
+
+	var raw []map[string]interface{}
+	qm, err := clients.Consul().Raw().Query(
+		"/v1/config/"+d.kind, &raw, opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	entries := make([]*dep.ConfigEntry, 0, len(raw))
+	for _, r := range raw {
+		entries = append(entries, configEntry(r))
+	}
+	sort.Stable(ByNamespaceThenName(entries))
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return entries, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *ConfigEntriesQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *ConfigEntriesQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *ConfigEntriesQuery) String() string {
+	name := d.kind
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	return fmt.Sprintf("config_entries(%s)", name)
+}
+
+func (d *ConfigEntriesQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// configEntry pulls the common fields out of the decoded config entry.
+func configEntry(raw map[string]interface{}) *dep.ConfigEntry {
+	entry := &dep.ConfigEntry{Config: raw}
+	entry.Kind, _ = raw["Kind"].(string)
+	entry.Name, _ = raw["Name"].(string)
+	entry.Namespace, _ = raw["Namespace"].(string)
+	if meta, ok := raw["Meta"].(map[string]interface{}); ok {
+		entry.Meta = make(map[string]string, len(meta))
+		for k, v := range meta {
+			entry.Meta[k] = fmt.Sprint(v)
+		}
+	}
+	if i, ok := raw["CreateIndex"].(float64); ok {
+		entry.CreateIndex = uint64(i)
+	}
+	if i, ok := raw["ModifyIndex"].(float64); ok {
+		entry.ModifyIndex = uint64(i)
+	}
+	return entry
+}
+
+// ByNamespaceThenName is a sortable slice of ConfigEntry
+type ByNamespaceThenName []*dep.ConfigEntry
+
+// Len, Swap, and Less are used to implement the sort.Sort interface.
+func (s ByNamespaceThenName) Len() int      { return len(s) }
+func (s ByNamespaceThenName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByNamespaceThenName) Less(i, j int) bool {
+	if s[i].Namespace == s[j].Namespace {
+		return s[i].Name < s[j].Name
+	}
+	return s[i].Namespace < s[j].Namespace
+}
@@ -0,0 +1,304 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfigEntryQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *ConfigEntryQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"kind_only",
+			"service-defaults",
+			nil,
+			true,
+		},
+		{
+			"name",
+			"service-resolver/web",
+			&ConfigEntryQuery{
+				kind: "service-resolver",
+				name: "web",
+			},
+			false,
+		},
+		{
+			"dc",
+			"proxy-defaults/global@dc1",
+			&ConfigEntryQuery{
+				kind: "proxy-defaults",
+				name: "global",
+				dc:   "dc1",
+			},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewConfigEntryQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestNewConfigEntriesQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *ConfigEntriesQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"with_name",
+			"service-defaults/web",
+			nil,
+			true,
+		},
+		{
+			"kind",
+			"ingress-gateway",
+			&ConfigEntriesQuery{
+				kind: "ingress-gateway",
+			},
+			false,
+		},
+		{
+			"dc",
+			"service-router@dc1",
+			&ConfigEntriesQuery{
+				kind: "service-router",
+				dc:   "dc1",
+			},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewConfigEntriesQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestConfigEntryQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"config-b", "config-a"} {
+		_, _, err := testClients.Consul().ConfigEntries().Set(
+			&api.ServiceConfigEntry{
+				Kind:     api.ServiceDefaults,
+				Name:     name,
+				Protocol: "http",
+			}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("single", func(t *testing.T) {
+		d, err := NewConfigEntryQuery("service-defaults/config-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, _, err := d.Fetch(testClients)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entry := act.(*dep.ConfigEntry)
+		assert.Equal(t, "service-defaults", entry.Kind)
+		assert.Equal(t, "config-a", entry.Name)
+		assert.Equal(t, "http", entry.Config["Protocol"])
+
+		typed, err := entry.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "http", typed.(*api.ServiceConfigEntry).Protocol)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		d, err := NewConfigEntryQuery("service-defaults/not-a-real-entry")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, _, err := d.Fetch(testClients)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, act)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		d, err := NewConfigEntriesQuery("service-defaults")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, _, err := d.Fetch(testClients)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := []string{}
+		for _, e := range act.([]*dep.ConfigEntry) {
+			names = append(names, e.Name)
+		}
+		assert.Equal(t, []string{"config-a", "config-b"}, names)
+	})
+
+	t.Run("missing-then-created", func(t *testing.T) {
+		d, err := NewConfigEntryQuery("service-defaults/config-c")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, rm, err := d.Fetch(testClients)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, act)
+
+		// block on the missing entry's index until it is created
+		d.SetOptions(QueryOptions{WaitIndex: rm.LastIndex, WaitTime: time.Minute})
+		type result struct {
+			act interface{}
+			rm  *dep.ResponseMetadata
+			err error
+		}
+		resCh := make(chan result, 1)
+		go func() {
+			act, rm, err := d.Fetch(testClients)
+			resCh <- result{act, rm, err}
+		}()
+
+		_, _, err = testClients.Consul().ConfigEntries().Set(
+			&api.ServiceConfigEntry{
+				Kind:     api.ServiceDefaults,
+				Name:     "config-c",
+				Protocol: "grpc",
+			}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case res := <-resCh:
+			if res.err != nil {
+				t.Fatal(res.err)
+			}
+			assert.Equal(t, "config-c", res.act.(*dep.ConfigEntry).Name)
+			assert.Greater(t, res.rm.LastIndex, rm.LastIndex)
+		case <-time.After(5 * time.Second):
+			t.Fatal("created entry not returned")
+		}
+	})
+}
+
+func TestConfigEntryQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"name",
+			"service-resolver/web",
+			"config_entry(service-resolver/web)",
+		},
+		{
+			"dc",
+			"service-resolver/web@dc1",
+			"config_entry(service-resolver/web@dc1)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewConfigEntryQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
+
+func TestConfigEntriesQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"kind",
+			"service-resolver",
+			"config_entries(service-resolver)",
+		},
+		{
+			"dc",
+			"service-resolver@dc1",
+			"config_entries(service-resolver@dc1)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewConfigEntriesQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
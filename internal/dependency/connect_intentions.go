@@ -0,0 +1,168 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*ConnectIntentionsQuery)(nil)
+
+	// ConnectIntentionsQueryRe is the regular expression to use for
+	// intentions. Without a match both source and destination are left empty
+	// and all intentions are returned.
+	ConnectIntentionsQueryRe = regexp.MustCompile(`\A` + intentionMatchRe + dcRe + `\z`)
+)
+
+const intentionMatchRe = `((?P<by>source|destination)=(?P<name>[[:word:]\-\_\*]+))?`
+
+func init() {
+	gob.Register([]*dep.Intention{})
+}
+
+// ConnectIntentionsQuery is the representation of Connect intentions in
+// Consul, either all of them or those matching a source or destination
+// service.
+type ConnectIntentionsQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	by   string
+	dc   string
+	name string
+	opts QueryOptions
+}
+
+// NewConnectIntentionsQuery processes the string to build an intentions
+// dependency. The format is [source=name|destination=name][@dc].
+func NewConnectIntentionsQuery(s string) (*ConnectIntentionsQuery, error) {
+	if !ConnectIntentionsQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("connect.intentions: invalid format: %q", s)
+	}
+
+	m := regexpMatch(ConnectIntentionsQueryRe, s)
+	return &ConnectIntentionsQuery{
+		stopCh: make(chan struct{}, 1),
+		by:     m["by"],
+		dc:     m["dc"],
+		name:   m["name"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a
+// slice of Intention objects, highest precedence first.
+func (d *ConnectIntentionsQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+	})
+
+	var ixns []*api.Intention
+	var qm *api.QueryMeta
+	var err error
+	if d.by == "" {
+		ixns, qm, err = clients.Consul().Connect().Intentions(opts.ToConsulOpts())
+	} else {
+		var matches map[string][]*api.Intention
+		matches, qm, err = clients.Consul().Connect().IntentionMatch(
+			&api.IntentionMatch{
+				By:    api.IntentionMatchType(d.by),
+				Names: []string{d.name},
+			}, opts.ToConsulOpts())
+		ixns = matches[d.name]
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	list := make([]*dep.Intention, 0, len(ixns))
+	for _, ixn := range ixns {
+		list = append(list, &dep.Intention{
+			ID:              ixn.ID,
+			Description:     ixn.Description,
+			SourceNS:        ixn.SourceNS,
+			SourceName:      ixn.SourceName,
+			DestinationNS:   ixn.DestinationNS,
+			DestinationName: ixn.DestinationName,
+			SourceType:      string(ixn.SourceType),
+			Action:          string(ixn.Action),
+			Meta:            ixn.Meta,
+			Precedence:      ixn.Precedence,
+			CreateIndex:     ixn.CreateIndex,
+			ModifyIndex:     ixn.ModifyIndex,
+		})
+	}
+	sort.Stable(ByPrecedence(list))
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return list, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *ConnectIntentionsQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *ConnectIntentionsQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *ConnectIntentionsQuery) String() string {
+	var name string
+	if d.by != "" {
+		name = d.by + "=" + d.name
+	}
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	if name == "" {
+		return "connect.intentions"
+	}
+	return fmt.Sprintf("connect.intentions(%s)", name)
+}
+
+func (d *ConnectIntentionsQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// ByPrecedence is a sortable slice of Intention, highest precedence first and
+// then by source, destination and ID so the order is always the same.
+type ByPrecedence []*dep.Intention
+
+// Len, Swap, and Less are used to implement the sort.Sort interface.
+func (s ByPrecedence) Len() int      { return len(s) }
+func (s ByPrecedence) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByPrecedence) Less(i, j int) bool {
+	a, b := s[i], s[j]
+	switch {
+	case a.Precedence != b.Precedence:
+		return a.Precedence > b.Precedence
+	case a.SourceNS != b.SourceNS:
+		return a.SourceNS < b.SourceNS
+	case a.SourceName != b.SourceName:
+		return a.SourceName < b.SourceName
+	case a.DestinationNS != b.DestinationNS:
+		return a.DestinationNS < b.DestinationNS
+	case a.DestinationName != b.DestinationName:
+		return a.DestinationName < b.DestinationName
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,204 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConnectIntentionsQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *ConnectIntentionsQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			&ConnectIntentionsQuery{},
+			false,
+		},
+		{
+			"dc_only",
+			"@dc1",
+			&ConnectIntentionsQuery{
+				dc: "dc1",
+			},
+			false,
+		},
+		{
+			"source",
+			"source=web",
+			&ConnectIntentionsQuery{
+				by:   "source",
+				name: "web",
+			},
+			false,
+		},
+		{
+			"destination_dc",
+			"destination=db@dc1",
+			&ConnectIntentionsQuery{
+				by:   "destination",
+				name: "db",
+				dc:   "dc1",
+			},
+			false,
+		},
+		{
+			"bad_match",
+			"upstream=db",
+			nil,
+			true,
+		},
+		{
+			"name_only",
+			"db",
+			nil,
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewConnectIntentionsQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestConnectIntentionsQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	for _, ixn := range []*api.Intention{
+		{
+			SourceName:      "*",
+			DestinationName: "ixn-db",
+			Action:          api.IntentionActionDeny,
+			SourceType:      api.IntentionSourceConsul,
+		},
+		{
+			SourceName:      "ixn-web",
+			DestinationName: "ixn-db",
+			Action:          api.IntentionActionAllow,
+			SourceType:      api.IntentionSourceConsul,
+		},
+	} {
+		id, _, err := testClients.Consul().Connect().IntentionCreate(ixn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer testClients.Consul().Connect().IntentionDelete(id, nil)
+	}
+
+	cases := []struct {
+		name string
+		i    string
+		exp  []string
+	}{
+		{
+			"destination",
+			"destination=ixn-db",
+			[]string{"ixn-web=>ixn-db", "*=>ixn-db"},
+		},
+		{
+			"source",
+			"source=ixn-web",
+			[]string{"ixn-web=>ixn-db", "*=>ixn-db"},
+		},
+		{
+			"unknown",
+			"destination=not-a-real-service",
+			[]string{},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewConnectIntentionsQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pairs := []string{}
+			for _, ixn := range act.([]*dep.Intention) {
+				pairs = append(pairs, ixn.SourceName+"=>"+ixn.DestinationName)
+			}
+			assert.Equal(t, tc.exp, pairs)
+		})
+	}
+}
+
+func TestConnectIntentionsQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"all",
+			"",
+			"connect.intentions",
+		},
+		{
+			"dc",
+			"@dc1",
+			"connect.intentions(@dc1)",
+		},
+		{
+			"destination",
+			"destination=db@dc1",
+			"connect.intentions(destination=db@dc1)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewConnectIntentionsQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
+
+func TestByPrecedence(t *testing.T) {
+	t.Parallel()
+
+	list := []*dep.Intention{
+		{ID: "4", SourceName: "*", DestinationName: "db", Precedence: 8},
+		{ID: "3", SourceName: "web", DestinationName: "db", Precedence: 9},
+		{ID: "2", SourceName: "api", DestinationName: "db", Precedence: 9},
+		{ID: "1", SourceName: "api", DestinationName: "cache", Precedence: 9},
+	}
+	sort.Stable(ByPrecedence(list))
+
+	ids := []string{}
+	for _, ixn := range list {
+		ids = append(ids, ixn.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+}
@@ -12,19 +12,25 @@ import (
 	"github.com/hashicorp/hcat/dep"
 )
 
+// Query string building blocks. paramsRe matches the optional "?" query
+// parameters (see queryParams) shared by all Consul queries. The key and
+// prefix are matched lazily so that parameters at their end are not taken as
+// part of them; a KV key that itself ends in eg. "?ns=name" (a valid key
+// before parameters were supported) is now read as a parameter.
 const (
 	dcRe          = `(@(?P<dc>[[:word:]\.\-\_]+))?`
-	keyRe         = `/?(?P<key>[^@]+)`
+	keyRe         = `/?(?P<key>[^@]+?)`
 	filterRe      = `(\|(?P<filter>[[:word:]\,]+))?`
 	serviceNameRe = `(?P<name>[[:word:]\-\_]+)`
 	nodeNameRe    = `(?P<name>[[:word:]\.\-\_]+)`
 	nearRe        = `(~(?P<near>[[:word:]\.\-\_]+))?`
-	prefixRe      = `/?(?P<prefix>[^@]+)`
+	prefixRe      = `/?(?P<prefix>[^@]+?)`
 	tagRe         = `((?P<tag>[[:word:]=:\.\-\_]+)\.)?`
+	paramsRe      = `(\?(?P<params>(ns|partition|node-meta|filter)=[^|]*))?`
 )
 
 // Type aliases to simplify things as we refactor
-//type QueryOptions = dep.QueryOptions
+// type QueryOptions = dep.QueryOptions
 type ResponseMetadata = dep.ResponseMetadata
 
 // Using interfaces for type annotations
@@ -67,7 +73,11 @@ type QueryOptionsSetter interface {
 type QueryOptions struct {
 	AllowStale        bool
 	Datacenter        string
+	Filter            string
+	Namespace         string
 	Near              string
+	NodeMeta          map[string]string
+	Partition         string
 	RequireConsistent bool
 	VaultGrace        time.Duration
 	WaitIndex         uint64
@@ -102,10 +112,26 @@ func (q *QueryOptions) Merge(o *QueryOptions) *QueryOptions {
 		r.Datacenter = o.Datacenter
 	}
 
+	if o.Filter != "" {
+		r.Filter = o.Filter
+	}
+
+	if o.Namespace != "" {
+		r.Namespace = o.Namespace
+	}
+
 	if o.Near != "" {
 		r.Near = o.Near
 	}
 
+	if len(o.NodeMeta) > 0 {
+		r.NodeMeta = o.NodeMeta
+	}
+
+	if o.Partition != "" {
+		r.Partition = o.Partition
+	}
+
 	if o.RequireConsistent != false {
 		r.RequireConsistent = o.RequireConsistent
 	}
@@ -134,7 +160,11 @@ func (q *QueryOptions) ToConsulOpts() *consulapi.QueryOptions {
 	cq := consulapi.QueryOptions{
 		AllowStale:        q.AllowStale,
 		Datacenter:        q.Datacenter,
+		Filter:            q.Filter,
+		Namespace:         q.Namespace,
 		Near:              q.Near,
+		NodeMeta:          q.NodeMeta,
+		Partition:         q.Partition,
 		RequireConsistent: q.RequireConsistent,
 		WaitIndex:         q.WaitIndex,
 		WaitTime:          q.WaitTime,
@@ -157,10 +187,26 @@ func (q *QueryOptions) String() string {
 		u.Add("dc", q.Datacenter)
 	}
 
+	if q.Filter != "" {
+		u.Add("filter", q.Filter)
+	}
+
+	if q.Namespace != "" {
+		u.Add("ns", q.Namespace)
+	}
+
 	if q.Near != "" {
 		u.Add("near", q.Near)
 	}
 
+	for _, k := range sortedKeys(q.NodeMeta) {
+		u.Add("node-meta", k+":"+q.NodeMeta[k])
+	}
+
+	if q.Partition != "" {
+		u.Add("partition", q.Partition)
+	}
+
 	if q.RequireConsistent {
 		u.Add("consistent", strconv.FormatBool(q.RequireConsistent))
 	}
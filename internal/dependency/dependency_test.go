@@ -104,7 +104,7 @@ func TestMain(m *testing.M) {
 }
 
 func runTestConsul() {
-	consul, err := testutil.NewTestServerConfig(
+	consul, err := testutil.NewTestServerConfigT(testingTB{"dependency"},
 		func(c *testutil.TestServerConfig) {
 			c.LogLevel = "warn"
 			c.Stdout = ioutil.Discard
@@ -218,3 +218,12 @@ func Fatalf(format string, args ...interface{}) {
 	fmt.Printf(format, args...)
 	runtime.Goexit()
 }
+
+// testingTB satisfies testutil.TestingTB to start the test server in
+// TestMain, where there is no *testing.T.
+type testingTB struct{ name string }
+
+func (testingTB) Cleanup(func())              {}
+func (testingTB) Failed() bool                { return false }
+func (testingTB) Logf(string, ...interface{}) {}
+func (tb testingTB) Name() string             { return tb.name }
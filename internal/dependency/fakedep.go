@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/hcat/dep"
@@ -116,6 +117,32 @@ func (d *FakeDepFetchError) String() string {
 func (d *FakeDepFetchError) Stop()                        {}
 func (d *FakeDepFetchError) SetOptions(opts QueryOptions) {}
 
+////////////
+var _ isDependency = (*FakeDepACLTokenExpired)(nil)
+
+// FakeDepACLTokenExpired is a fake dependency that counts its fetches and
+// always returns an expired ACL token error.
+type FakeDepACLTokenExpired struct {
+	Fetches int32
+}
+
+func (d *FakeDepACLTokenExpired) Fetch(dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	atomic.AddInt32(&d.Fetches, 1)
+	time.Sleep(time.Microsecond)
+	return nil, nil, &dep.ACLTokenExpiredError{AccessorID: "fake"}
+}
+
+func (d *FakeDepACLTokenExpired) CanShare() bool {
+	return true
+}
+
+func (d *FakeDepACLTokenExpired) String() string {
+	return "test_dep_acl_token_expired"
+}
+
+func (d *FakeDepACLTokenExpired) Stop()                        {}
+func (d *FakeDepACLTokenExpired) SetOptions(opts QueryOptions) {}
+
 ////////////
 var _ isDependency = (*FakeDepSameIndex)(nil)
 
@@ -0,0 +1,306 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*HealthStateQuery)(nil)
+	_ isDependency = (*HealthNodeQuery)(nil)
+
+	// HealthStateQueryRe is the regular expression to use for checks by
+	// state.
+	HealthStateQueryRe = regexp.MustCompile(`\A` + stateRe + dcRe + nearRe + paramsRe + `\z`)
+
+	// HealthNodeQueryRe is the regular expression to use for checks by node.
+	HealthNodeQueryRe = regexp.MustCompile(`\A` + nodeNameRe + dcRe + paramsRe + filterRe + `\z`)
+)
+
+const stateRe = `(?P<state>[[:word:]]+)`
+
+func init() {
+	gob.Register([]*dep.HealthCheck{})
+}
+
+// HealthStateQuery is the representation of a query for the health checks in
+// a state in Consul.
+type HealthStateQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc     string
+	params queryParams
+	near   string
+	state  string
+	opts   QueryOptions
+}
+
+// NewHealthStateQuery processes the strings to build a checks by state
+// dependency. The state is one of any, passing, warning or critical.
+func NewHealthStateQuery(s string) (*HealthStateQuery, error) {
+	if !HealthStateQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("health.state: invalid format: %q", s)
+	}
+
+	m := regexpMatch(HealthStateQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("health.state: %s in %q", err, s)
+	}
+
+	switch m["state"] {
+	case HealthAny, HealthPassing, HealthWarning, HealthCritical:
+	default:
+		return nil, fmt.Errorf("health.state: invalid state: %q in %q",
+			m["state"], s)
+	}
+
+	return &HealthStateQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		params: params,
+		near:   m["near"],
+		state:  m["state"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a slice
+// of HealthCheck objects.
+func (d *HealthStateQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
+		Near:       d.near,
+	})
+
+	checks, qm, err := clients.Consul().Health().State(d.state, opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	list := healthChecks(checks, nil)
+
+	// Sort unless the user explicitly asked for nearness
+	if d.near == "" {
+		sort.Stable(ByNodeThenCheckID(list))
+	}
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return list, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *HealthStateQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *HealthStateQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *HealthStateQuery) String() string {
+	name := d.state
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	if d.near != "" {
+		name = name + "~" + d.near
+	}
+	name = name + d.params.String()
+	return fmt.Sprintf("health.state(%s)", name)
+}
+
+func (d *HealthStateQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// HealthNodeQuery is the representation of a query for the health checks of
+// a node in Consul.
+type HealthNodeQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc      string
+	params  queryParams
+	filters []string
+	name    string
+	opts    QueryOptions
+}
+
+// NewHealthNodeQuery processes the strings to build a checks by node
+// dependency. If the name is empty then the node of the local agent is used.
+// Checks can be filtered by status like the health service query.
+func NewHealthNodeQuery(s string) (*HealthNodeQuery, error) {
+	if s != "" && !HealthNodeQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("health.node: invalid format: %q", s)
+	}
+
+	m := regexpMatch(HealthNodeQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("health.node: %s in %q", err, s)
+	}
+
+	var filters []string
+	if filter := m["filter"]; filter != "" {
+		for _, f := range strings.Split(filter, ",") {
+			f = strings.TrimSpace(f)
+			switch f {
+			case HealthAny,
+				HealthPassing,
+				HealthWarning,
+				HealthCritical,
+				HealthMaint:
+				filters = append(filters, f)
+			case "":
+			default:
+				return nil, fmt.Errorf(
+					"health.node: invalid filter: %q in %q", f, s)
+			}
+		}
+		sort.Strings(filters)
+	}
+
+	return &HealthNodeQuery{
+		stopCh:  make(chan struct{}, 1),
+		dc:      m["dc"],
+		params:  params,
+		filters: filters,
+		name:    m["name"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a slice
+// of HealthCheck objects.
+func (d *HealthNodeQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
+	})
+
+	name := d.name
+	if name == "" {
+		var err error
+		name, err = clients.Consul().Agent().NodeName()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, d.String())
+		}
+	}
+
+	checks, qm, err := clients.Consul().Health().Node(name, opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	list := healthChecks(checks, d.filters)
+	sort.Stable(ByNodeThenCheckID(list))
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return list, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable. Queries for
+// the local agent's node can't be shared as it depends on the client.
+func (d *HealthNodeQuery) CanShare() bool {
+	return d.name != ""
+}
+
+// Stop halts the dependency's fetch function.
+func (d *HealthNodeQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *HealthNodeQuery) String() string {
+	name := d.name
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	name = name + d.params.String()
+	if len(d.filters) > 0 {
+		name = name + "|" + strings.Join(d.filters, ",")
+	}
+	if name == "" {
+		return "health.node"
+	}
+	return fmt.Sprintf("health.node(%s)", name)
+}
+
+func (d *HealthNodeQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// healthChecks converts the API checks, keeping those with a status accepted
+// by the filters (all if there are none).
+func healthChecks(checks api.HealthChecks, filters []string) []*dep.HealthCheck {
+	list := make([]*dep.HealthCheck, 0, len(checks))
+	for _, c := range checks {
+		if len(filters) > 0 && !acceptStatus(filters, c.Status) {
+			continue
+		}
+		list = append(list, &dep.HealthCheck{
+			Node:        c.Node,
+			CheckID:     c.CheckID,
+			Name:        c.Name,
+			Status:      c.Status,
+			Notes:       c.Notes,
+			Output:      c.Output,
+			ServiceID:   c.ServiceID,
+			ServiceName: c.ServiceName,
+			ServiceTags: dep.ServiceTags(deepCopyAndSortTags(c.ServiceTags)),
+			Type:        c.Type,
+			Namespace:   c.Namespace,
+		})
+	}
+	return list
+}
+
+// ByNodeThenCheckID is a sortable slice of HealthCheck
+type ByNodeThenCheckID []*dep.HealthCheck
+
+// Len, Swap, and Less are used to implement the sort.Sort interface.
+func (s ByNodeThenCheckID) Len() int      { return len(s) }
+func (s ByNodeThenCheckID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByNodeThenCheckID) Less(i, j int) bool {
+	if s[i].Node == s[j].Node {
+		return s[i].CheckID < s[j].CheckID
+	}
+	return s[i].Node < s[j].Node
+}
@@ -0,0 +1,347 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthStateQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *HealthStateQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"invalid_state",
+			"maintenance",
+			nil,
+			true,
+		},
+		{
+			"state",
+			"critical",
+			&HealthStateQuery{
+				state: "critical",
+			},
+			false,
+		},
+		{
+			"dc",
+			"any@dc1",
+			&HealthStateQuery{
+				state: "any",
+				dc:    "dc1",
+			},
+			false,
+		},
+		{
+			"near",
+			"warning~_agent",
+			&HealthStateQuery{
+				state: "warning",
+				near:  "_agent",
+			},
+			false,
+		},
+		{
+			"dc_near",
+			"passing@dc1~_agent",
+			&HealthStateQuery{
+				state: "passing",
+				dc:    "dc1",
+				near:  "_agent",
+			},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewHealthStateQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestNewHealthNodeQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *HealthNodeQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			&HealthNodeQuery{},
+			false,
+		},
+		{
+			"bad",
+			"!4d",
+			nil,
+			true,
+		},
+		{
+			"node",
+			"node1",
+			&HealthNodeQuery{
+				name: "node1",
+			},
+			false,
+		},
+		{
+			"dc",
+			"node1@dc1",
+			&HealthNodeQuery{
+				name: "node1",
+				dc:   "dc1",
+			},
+			false,
+		},
+		{
+			"filters",
+			"node1|warning,critical",
+			&HealthNodeQuery{
+				name:    "node1",
+				filters: []string{"critical", "warning"},
+			},
+			false,
+		},
+		{
+			"invalid_filter",
+			"node1|nope",
+			nil,
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewHealthNodeQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestHealthStateQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  []string
+	}{
+		{
+			"any",
+			"any",
+			[]string{"serfHealth"},
+		},
+		{
+			"passing",
+			"passing",
+			[]string{"serfHealth"},
+		},
+		{
+			"critical",
+			"critical",
+			[]string{},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewHealthStateQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []string{}
+			for _, c := range act.([]*dep.HealthCheck) {
+				ids = append(ids, c.CheckID)
+			}
+
+			assert.Equal(t, tc.exp, ids)
+		})
+	}
+}
+
+func TestHealthNodeQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  []string
+	}{
+		{
+			"local",
+			"",
+			[]string{"serfHealth"},
+		},
+		{
+			"filtered_out",
+			testConsul.Config.NodeName + "|critical",
+			[]string{},
+		},
+		{
+			"unknown",
+			"not_a_real_node",
+			[]string{},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewHealthNodeQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []string{}
+			for _, c := range act.([]*dep.HealthCheck) {
+				ids = append(ids, c.CheckID)
+			}
+
+			assert.Equal(t, tc.exp, ids)
+		})
+	}
+}
+
+func TestHealthNodeQuery_CanShare(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  bool
+	}{
+		{
+			"local",
+			"",
+			false,
+		},
+		{
+			"node",
+			"node1@dc1",
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewHealthNodeQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.CanShare())
+		})
+	}
+}
+
+func TestHealthStateQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"state",
+			"critical",
+			"health.state(critical)",
+		},
+		{
+			"dc_near",
+			"any@dc1~_agent",
+			"health.state(any@dc1~_agent)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewHealthStateQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
+
+func TestHealthNodeQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"empty",
+			"",
+			"health.node",
+		},
+		{
+			"node",
+			"node1",
+			"health.node(node1)",
+		},
+		{
+			"dc_filters",
+			"node1@dc1|passing,warning",
+			"health.node(node1@dc1|passing,warning)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewHealthNodeQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/hcat/dep"
 	"github.com/pkg/errors"
 )
@@ -27,7 +28,7 @@ var (
 	_ isDependency = (*HealthServiceQuery)(nil)
 
 	// HealthServiceQueryRe is the regular expression to use.
-	HealthServiceQueryRe = regexp.MustCompile(`\A` + tagRe + serviceNameRe + dcRe + nearRe + filterRe + `\z`)
+	HealthServiceQueryRe = regexp.MustCompile(`\A` + tagRe + serviceNameRe + dcRe + nearRe + paramsRe + filterRe + `\z`)
 )
 
 func init() {
@@ -40,6 +41,7 @@ type HealthServiceQuery struct {
 	stopCh chan struct{}
 
 	dc      string
+	params  queryParams
 	filters []string
 	name    string
 	near    string
@@ -64,6 +66,10 @@ func healthServiceQuery(s string, connect bool) (*HealthServiceQuery, error) {
 	}
 
 	m := regexpMatch(HealthServiceQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err != nil {
+		return nil, fmt.Errorf("health.service: %s in %q", err, s)
+	}
 
 	var filters []string
 	if filter := m["filter"]; filter != "" {
@@ -91,6 +97,7 @@ func healthServiceQuery(s string, connect bool) (*HealthServiceQuery, error) {
 	return &HealthServiceQuery{
 		stopCh:  make(chan struct{}, 1),
 		dc:      m["dc"],
+		params:  params,
 		filters: filters,
 		name:    m["name"],
 		near:    m["near"],
@@ -110,6 +117,10 @@ func (d *HealthServiceQuery) Fetch(clients dep.Clients) (interface{}, *dep.Respo
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+		NodeMeta:   d.params.nodeMeta,
+		Filter:     d.params.filter,
 		Near:       d.near,
 	})
 
@@ -145,32 +156,7 @@ func (d *HealthServiceQuery) Fetch(clients dep.Clients) (interface{}, *dep.Respo
 			continue
 		}
 
-		// Get the address of the service, falling back to the address of the
-		// node.
-		address := entry.Service.Address
-		if address == "" {
-			address = entry.Node.Address
-		}
-
-		list = append(list, &dep.HealthService{
-			Node:                entry.Node.Node,
-			NodeID:              entry.Node.ID,
-			NodeAddress:         entry.Node.Address,
-			NodeDatacenter:      entry.Node.Datacenter,
-			NodeTaggedAddresses: entry.Node.TaggedAddresses,
-			NodeMeta:            entry.Node.Meta,
-			ServiceMeta:         entry.Service.Meta,
-			Address:             address,
-			ID:                  entry.Service.ID,
-			Name:                entry.Service.Service,
-			Tags: dep.ServiceTags(
-				deepCopyAndSortTags(entry.Service.Tags)),
-			Status:    status,
-			Checks:    entry.Checks,
-			Port:      entry.Service.Port,
-			Weights:   entry.Service.Weights,
-			Namespace: entry.Service.Namespace,
-		})
+		list = append(list, healthServiceFromEntry(entry, status))
 	}
 
 	//log.Printf("[TRACE] %s: returned %d results after filtering", d, len(list))
@@ -210,6 +196,7 @@ func (d *HealthServiceQuery) String() string {
 	if d.near != "" {
 		name = name + "~" + d.near
 	}
+	name = name + d.params.String()
 	if len(d.filters) > 0 {
 		name = name + "|" + strings.Join(d.filters, ",")
 	}
@@ -244,3 +231,34 @@ func (s ByNodeThenID) Less(i, j int) bool {
 	}
 	return false
 }
+
+// healthServiceFromEntry converts a service entry returned by Consul into a
+// HealthService with the given aggregated status.
+func healthServiceFromEntry(entry *api.ServiceEntry, status string) *dep.HealthService {
+	// Get the address of the service, falling back to the address of the
+	// node.
+	address := entry.Service.Address
+	if address == "" {
+		address = entry.Node.Address
+	}
+
+	return &dep.HealthService{
+		Node:                entry.Node.Node,
+		NodeID:              entry.Node.ID,
+		NodeAddress:         entry.Node.Address,
+		NodeDatacenter:      entry.Node.Datacenter,
+		NodeTaggedAddresses: entry.Node.TaggedAddresses,
+		NodeMeta:            entry.Node.Meta,
+		ServiceMeta:         entry.Service.Meta,
+		Address:             address,
+		ID:                  entry.Service.ID,
+		Name:                entry.Service.Service,
+		Tags: dep.ServiceTags(
+			deepCopyAndSortTags(entry.Service.Tags)),
+		Status:    status,
+		Checks:    entry.Checks,
+		Port:      entry.Service.Port,
+		Weights:   entry.Service.Weights,
+		Namespace: entry.Service.Namespace,
+	}
+}
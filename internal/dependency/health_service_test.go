@@ -126,6 +126,48 @@ func TestNewHealthServiceQuery(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"name_ns_filter",
+			"name?ns=team-a|any",
+			&HealthServiceQuery{
+				filters: []string{"any"},
+				name:    "name",
+				params:  queryParams{ns: "team-a"},
+			},
+			false,
+		},
+		{
+			"name_params_filter",
+			"name?node-meta=rack:r1&filter=Service.Meta.version == \"2\"|any",
+			&HealthServiceQuery{
+				filters: []string{"any"},
+				name:    "name",
+				params: queryParams{
+					nodeMeta: map[string]string{"rack": "r1"},
+					filter:   `Service.Meta.version == "2"`,
+				},
+			},
+			false,
+		},
+		{
+			"bad_param",
+			"name?nope=1",
+			nil,
+			true,
+		},
+		{
+			"tag_name_dc_near_ns",
+			"tag.name@dc~near?ns=team-a",
+			&HealthServiceQuery{
+				dc:      "dc",
+				filters: []string{"passing"},
+				name:    "name",
+				near:    "near",
+				params:  queryParams{ns: "team-a"},
+				tag:     "tag",
+			},
+			false,
+		},
 	}
 
 	for i, tc := range cases {
@@ -398,6 +440,26 @@ func TestHealthServiceQuery_String(t *testing.T) {
 			"name@dc~near",
 			"health.service(name@dc~near|passing)",
 		},
+		{
+			"name_dc_near_ns_filter",
+			"name@dc~near?ns=team-a|any",
+			"health.service(name@dc~near?ns=team-a|any)",
+		},
+		{
+			"filter_takes_rest",
+			"name?filter=Node.Meta.rack == r1&node-meta=a:b",
+			"health.service(name?filter=Node.Meta.rack == r1&node-meta=a:b|passing)",
+		},
+		{
+			"name_params_ordered",
+			"name?node-meta=b:2&ns=team-a&node-meta=a:1",
+			"health.service(name?ns=team-a&node-meta=a:1&node-meta=b:2|passing)",
+		},
+		{
+			"name_partition",
+			"name@dc1?partition=eu&ns=team-a|any",
+			"health.service(name@dc1?ns=team-a&partition=eu|any)",
+		},
 		{
 			"name_dc_near_filter",
 			"name@dc~near|any",
@@ -14,7 +14,7 @@ var (
 	_ BlockingQuery = (*KVGetQuery)(nil)
 
 	// KVGetQueryRe is the regular expression to use.
-	KVGetQueryRe = regexp.MustCompile(`\A` + keyRe + dcRe + `\z`)
+	KVGetQueryRe = regexp.MustCompile(`\A` + keyRe + dcRe + paramsRe + `\z`)
 )
 
 // KVExistsQuery uses a non-blocking query with the KV store for key lookup.
@@ -22,9 +22,10 @@ type KVExistsQuery struct {
 	isConsul
 	stopCh chan struct{}
 
-	dc   string
-	key  string
-	opts QueryOptions
+	dc     string
+	params queryParams
+	key    string
+	opts   QueryOptions
 }
 
 // KVGetQuery queries the KV store for a single key.
@@ -43,6 +44,7 @@ func (d *KVExistsQuery) String() string {
 	if d.dc != "" {
 		key = key + "@" + d.dc
 	}
+	key = key + d.params.String()
 	return fmt.Sprintf("kv.exists(%s)", key)
 }
 
@@ -53,9 +55,18 @@ func NewKVExistsQuery(s string) (*KVExistsQuery, error) {
 	}
 
 	m := regexpMatch(KVGetQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err == nil {
+		err = params.only()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kv.get: %s in %q", err, s)
+	}
+
 	return &KVExistsQuery{
 		stopCh: make(chan struct{}, 1),
 		dc:     m["dc"],
+		params: params,
 		key:    m["key"],
 	}, nil
 }
@@ -79,6 +90,8 @@ func (d *KVGetQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetad
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
 	})
 
 	//log.Printf("[TRACE] %s: GET %s", d, &url.URL{
@@ -117,6 +130,7 @@ func (d *KVGetQuery) String() string {
 	if d.dc != "" {
 		key = key + "@" + d.dc
 	}
+	key = key + d.params.String()
 
 	return fmt.Sprintf("kv.get(%s)", key)
 }
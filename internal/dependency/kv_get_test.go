@@ -98,6 +98,48 @@ func TestNewKVGetQuery(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"ns",
+			"key?ns=team-a",
+			&KVExistsQuery{
+				key:    "key",
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
+		{
+			"dc_ns",
+			"key@dc1?ns=team-a",
+			&KVExistsQuery{
+				key:    "key",
+				dc:     "dc1",
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
+		{
+			"ns_partition",
+			"key?ns=team-a&partition=eu",
+			&KVExistsQuery{
+				key:    "key",
+				params: queryParams{ns: "team-a", partition: "eu"},
+			},
+			false,
+		},
+		{
+			"filter",
+			"key?filter=Key != \"\"",
+			nil,
+			true,
+		},
+		{
+			"question_mark",
+			"key?with=mark",
+			&KVExistsQuery{
+				key: "key?with=mark",
+			},
+			false,
+		},
 		{
 			"dots",
 			"key.with.dots",
@@ -325,6 +367,12 @@ func TestKVGetQuery_String(t *testing.T) {
 			"kv.exists(key@dc1)",
 			NewKVExistsQuery,
 		},
+		{
+			"dc_ns",
+			"key@dc1?ns=team-a",
+			"kv.get(key@dc1?ns=team-a)",
+			NewKVGetQuery,
+		},
 	}
 
 	for i, tc := range cases {
@@ -14,7 +14,7 @@ var (
 	_ isDependency = (*KVKeysQuery)(nil)
 
 	// KVKeysQueryRe is the regular expression to use.
-	KVKeysQueryRe = regexp.MustCompile(`\A` + prefixRe + dcRe + `\z`)
+	KVKeysQueryRe = regexp.MustCompile(`\A` + prefixRe + dcRe + paramsRe + `\z`)
 )
 
 // KVKeysQuery queries the KV store for a single key.
@@ -23,6 +23,7 @@ type KVKeysQuery struct {
 	stopCh chan struct{}
 
 	dc     string
+	params queryParams
 	prefix string
 	opts   QueryOptions
 }
@@ -34,9 +35,18 @@ func NewKVKeysQuery(s string) (*KVKeysQuery, error) {
 	}
 
 	m := regexpMatch(KVKeysQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err == nil {
+		err = params.only()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kv.keys: %s in %q", err, s)
+	}
+
 	return &KVKeysQuery{
 		stopCh: make(chan struct{}, 1),
 		dc:     m["dc"],
+		params: params,
 		prefix: m["prefix"],
 	}, nil
 }
@@ -51,6 +61,8 @@ func (d *KVKeysQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMeta
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
 	})
 
 	//log.Printf("[TRACE] %s: GET %s", d, &url.URL{
@@ -91,6 +103,7 @@ func (d *KVKeysQuery) String() string {
 	if d.dc != "" {
 		prefix = prefix + "@" + d.dc
 	}
+	prefix = prefix + d.params.String()
 	return fmt.Sprintf("kv.keys(%s)", prefix)
 }
 
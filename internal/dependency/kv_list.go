@@ -15,7 +15,7 @@ var (
 	_ isDependency = (*KVListQuery)(nil)
 
 	// KVListQueryRe is the regular expression to use.
-	KVListQueryRe = regexp.MustCompile(`\A` + prefixRe + dcRe + `\z`)
+	KVListQueryRe = regexp.MustCompile(`\A` + prefixRe + dcRe + paramsRe + `\z`)
 )
 
 func init() {
@@ -28,6 +28,7 @@ type KVListQuery struct {
 	stopCh chan struct{}
 
 	dc     string
+	params queryParams
 	prefix string
 	opts   QueryOptions
 }
@@ -39,9 +40,18 @@ func NewKVListQuery(s string) (*KVListQuery, error) {
 	}
 
 	m := regexpMatch(KVListQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err == nil {
+		err = params.only()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kv.list: %s in %q", err, s)
+	}
+
 	return &KVListQuery{
 		stopCh: make(chan struct{}, 1),
 		dc:     m["dc"],
+		params: params,
 		prefix: m["prefix"],
 	}, nil
 }
@@ -56,6 +66,8 @@ func (d *KVListQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMeta
 
 	opts := d.opts.Merge(&QueryOptions{
 		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
 	})
 
 	//log.Printf("[TRACE] %s: GET %s", d, &url.URL{
@@ -106,6 +118,7 @@ func (d *KVListQuery) String() string {
 	if d.dc != "" {
 		prefix = prefix + "@" + d.dc
 	}
+	prefix = prefix + d.params.String()
 	return fmt.Sprintf("kv.list(%s)", prefix)
 }
 
@@ -0,0 +1,128 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*KVLockQuery)(nil)
+
+	// KVLockQueryRe is the regular expression to use.
+	KVLockQueryRe = regexp.MustCompile(`\A` + keyRe + dcRe + paramsRe + `\z`)
+)
+
+func init() {
+	gob.Register(&dep.Session{})
+}
+
+// KVLockQuery queries the session holding the lock on a key in the KV store.
+type KVLockQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc     string
+	params queryParams
+	key    string
+	opts   QueryOptions
+}
+
+// NewKVLockQuery parses a string into a lock holder dependency.
+func NewKVLockQuery(s string) (*KVLockQuery, error) {
+	if !KVLockQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("kv.lock: invalid format: %q", s)
+	}
+
+	m := regexpMatch(KVLockQueryRe, s)
+	params, err := parseQueryParams(m["params"])
+	if err == nil {
+		err = params.only()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kv.lock: %s in %q", err, s)
+	}
+
+	return &KVLockQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		params: params,
+		key:    m["key"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns the
+// Session holding the lock, or nil if the key is missing or not locked. It
+// blocks on the key, which changes whenever the lock is acquired or released.
+func (d *KVLockQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+		Namespace:  d.params.ns,
+		Partition:  d.params.partition,
+	})
+
+	pair, qm, err := clients.Consul().KV().Get(d.key, opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	if pair == nil || pair.Session == "" {
+		return nil, rm, nil
+	}
+
+	// The session is read right away, the wait only applies to the key.
+	opts.WaitIndex = 0
+	opts.WaitTime = 0
+	entry, _, err := clients.Consul().Session().Info(pair.Session,
+		opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	// The session was destroyed after the key was read, the lock is about to
+	// be released.
+	if entry == nil {
+		return nil, rm, nil
+	}
+
+	return session(entry), rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *KVLockQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *KVLockQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *KVLockQuery) String() string {
+	key := d.key
+	if d.dc != "" {
+		key = key + "@" + d.dc
+	}
+	key = key + d.params.String()
+	return fmt.Sprintf("kv.lock(%s)", key)
+}
+
+func (d *KVLockQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
@@ -0,0 +1,126 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKVLockQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *KVLockQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"key",
+			"service/web/leader",
+			&KVLockQuery{
+				key: "service/web/leader",
+			},
+			false,
+		},
+		{
+			"dc_ns",
+			"/service/web/leader@dc1?ns=team-a",
+			&KVLockQuery{
+				key:    "service/web/leader",
+				dc:     "dc1",
+				params: queryParams{ns: "team-a"},
+			},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewKVLockQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestKVLockQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	consul := testClients.Consul()
+	id, _, err := consul.Session().CreateNoChecks(
+		&api.SessionEntry{Name: "lock-test"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consul.Session().Destroy(id, nil)
+
+	ok, _, err := consul.KV().Acquire(&api.KVPair{
+		Key:     "test-kv-lock/locked",
+		Value:   []byte("node"),
+		Session: id,
+	}, nil)
+	if err != nil || !ok {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+	testConsul.SetKVString(t, "test-kv-lock/unlocked", "free")
+
+	t.Run("locked", func(t *testing.T) {
+		d, err := NewKVLockQuery("test-kv-lock/locked")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, _, err := d.Fetch(testClients)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s, ok := act.(*dep.Session); assert.True(t, ok) {
+			assert.Equal(t, id, s.ID)
+			assert.Equal(t, "lock-test", s.Name)
+			assert.Equal(t, testConsul.Config.NodeName, s.Node)
+		}
+	})
+
+	for _, key := range []string{"test-kv-lock/unlocked", "test-kv-lock/nope"} {
+		t.Run(key, func(t *testing.T) {
+			d, err := NewKVLockQuery(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, act)
+		})
+	}
+}
+
+func TestKVLockQuery_String(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewKVLockQuery("service/web/leader@dc1?ns=team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kv.lock(service/web/leader@dc1?ns=team-a)", d.String())
+}
@@ -0,0 +1,153 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*PreparedQuery)(nil)
+
+	// PreparedQueryRe is the regular expression to use for prepared queries.
+	PreparedQueryRe = regexp.MustCompile(`\A` + queryNameRe + dcRe + nearRe + `\z`)
+
+	// PreparedQuerySleepTime is the default amount of time to sleep between
+	// queries, since prepared queries do not support blocking queries.
+	PreparedQuerySleepTime = 15 * time.Second
+)
+
+const queryNameRe = `(?P<name>[[:word:]\.\-\_]+)`
+
+func init() {
+	gob.Register(&dep.PreparedQueryResult{})
+}
+
+// PreparedQuery is the representation of a prepared query execution in
+// Consul.
+type PreparedQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc       string
+	name     string
+	near     string
+	interval time.Duration
+	opts     QueryOptions
+}
+
+// NewPreparedQuery processes the string to build a prepared query dependency.
+// The name may be the name or the ID of the query. The interval is the time
+// between executions, zero uses PreparedQuerySleepTime.
+func NewPreparedQuery(s string, interval time.Duration) (*PreparedQuery, error) {
+	if !PreparedQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("prepared_query: invalid format: %q", s)
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("prepared_query: invalid interval: %s", interval)
+	}
+	if interval == 0 {
+		interval = PreparedQuerySleepTime
+	}
+
+	m := regexpMatch(PreparedQueryRe, s)
+	return &PreparedQuery{
+		stopCh:   make(chan struct{}, 1),
+		dc:       m["dc"],
+		name:     m["name"],
+		near:     m["near"],
+		interval: interval,
+	}, nil
+}
+
+// Fetch executes the prepared query using the given client and returns a
+// PreparedQueryResult.
+func (d *PreparedQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+		Near:       d.near,
+	})
+
+	// Prepared queries do not support blocking queries, so poll at the
+	// interval once we have returned data.
+	if opts.WaitIndex != 0 {
+		select {
+		case <-d.stopCh:
+			return nil, nil, ErrStopped
+		case <-time.After(d.interval):
+		}
+	}
+
+	// The wait options are meaningless here and only make sense for the
+	// blocking endpoints.
+	opts.WaitIndex = 0
+	opts.WaitTime = 0
+
+	resp, _, err := clients.Consul().PreparedQuery().Execute(d.name,
+		opts.ToConsulOpts())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	nodes := make([]*dep.HealthService, 0, len(resp.Nodes))
+	for i := range resp.Nodes {
+		entry := &resp.Nodes[i]
+		nodes = append(nodes,
+			healthServiceFromEntry(entry, entry.Checks.AggregatedStatus()))
+	}
+
+	// Consul shuffles the nodes unless they are sorted by nearness, sort them
+	// so unchanged results compare equal between polls.
+	if d.near == "" {
+		sort.Stable(ByNodeThenID(nodes))
+	}
+
+	return respWithMetadata(&dep.PreparedQueryResult{
+		Service:    resp.Service,
+		Namespace:  resp.Namespace,
+		Nodes:      nodes,
+		Datacenter: resp.Datacenter,
+		Failovers:  resp.Failovers,
+	})
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *PreparedQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *PreparedQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *PreparedQuery) String() string {
+	name := d.name
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	if d.near != "" {
+		name = name + "~" + d.near
+	}
+	if d.interval != PreparedQuerySleepTime {
+		name = name + " " + d.interval.String()
+	}
+	return fmt.Sprintf("prepared_query(%s)", name)
+}
+
+func (d *PreparedQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
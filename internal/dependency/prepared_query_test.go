@@ -0,0 +1,175 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPreparedQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		i        string
+		interval time.Duration
+		exp      *PreparedQuery
+		err      bool
+	}{
+		{
+			"empty",
+			"",
+			0,
+			nil,
+			true,
+		},
+		{
+			"dc_only",
+			"@dc1",
+			0,
+			nil,
+			true,
+		},
+		{
+			"negative_interval",
+			"web",
+			-time.Second,
+			nil,
+			true,
+		},
+		{
+			"name",
+			"web-failover",
+			0,
+			&PreparedQuery{
+				name:     "web-failover",
+				interval: PreparedQuerySleepTime,
+			},
+			false,
+		},
+		{
+			"dc_near",
+			"web@dc1~_agent",
+			0,
+			&PreparedQuery{
+				name:     "web",
+				dc:       "dc1",
+				near:     "_agent",
+				interval: PreparedQuerySleepTime,
+			},
+			false,
+		},
+		{
+			"interval",
+			"web",
+			time.Minute,
+			&PreparedQuery{
+				name:     "web",
+				interval: time.Minute,
+			},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewPreparedQuery(tc.i, tc.interval)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestPreparedQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	id, _, err := testClients.Consul().PreparedQuery().Create(
+		&api.PreparedQueryDefinition{
+			Name:    "consul-query",
+			Service: api.ServiceQuery{Service: "consul"},
+		}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClients.Consul().PreparedQuery().Delete(id, nil)
+
+	d, err := NewPreparedQuery("consul-query", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act, _, err := d.Fetch(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := act.(*dep.PreparedQueryResult)
+	assert.Equal(t, "consul", res.Service)
+	assert.Equal(t, "dc1", res.Datacenter)
+	assert.Equal(t, 0, res.Failovers)
+	if assert.Len(t, res.Nodes, 1) {
+		assert.Equal(t, testConsul.Config.NodeName, res.Nodes[0].Node)
+		assert.Equal(t, "passing", res.Nodes[0].Status)
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		d, err := NewPreparedQuery("not-a-real-query", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := d.Fetch(testClients); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestPreparedQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		i        string
+		interval time.Duration
+		exp      string
+	}{
+		{
+			"name",
+			"web",
+			0,
+			"prepared_query(web)",
+		},
+		{
+			"dc_near",
+			"web@dc1~_agent",
+			0,
+			"prepared_query(web@dc1~_agent)",
+		},
+		{
+			"interval",
+			"web",
+			30 * time.Second,
+			"prepared_query(web 30s)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewPreparedQuery(tc.i, tc.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
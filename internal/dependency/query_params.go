@@ -0,0 +1,121 @@
+package dependency
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var nsNameRe = regexp.MustCompile(`\A[[:word:]\-\_]+\z`)
+
+// queryParams are the optional parameters given after a "?" at the end of a
+// Consul query, separated by "&". For example
+//
+//	web@dc1?ns=team-a&partition=eu&node-meta=rack:r1&filter=Service.Meta.version == "2"
+//
+// The filter is a Consul filter expression and, as it may contain anything,
+// it takes the rest of the query so it must come last. Queries for endpoints
+// without node-meta or filter support only accept ns and partition, see only.
+type queryParams struct {
+	ns        string
+	partition string
+	nodeMeta  map[string]string
+	filter    string
+}
+
+// parseQueryParams parses the parameters matched by paramsRe.
+func parseQueryParams(s string) (queryParams, error) {
+	var p queryParams
+	for s != "" {
+		if strings.HasPrefix(s, "filter=") {
+			p.filter = strings.TrimPrefix(s, "filter=")
+			if p.filter == "" {
+				return p, fmt.Errorf("empty filter")
+			}
+			break
+		}
+
+		param := s
+		s = ""
+		if i := strings.Index(param, "&"); i >= 0 {
+			param, s = param[:i], param[i+1:]
+		}
+
+		i := strings.Index(param, "=")
+		if i < 0 {
+			return p, fmt.Errorf("invalid parameter: %q", param)
+		}
+		key, value := param[:i], param[i+1:]
+
+		switch key {
+		case "ns":
+			if !nsNameRe.MatchString(value) {
+				return p, fmt.Errorf("invalid namespace: %q", value)
+			}
+			p.ns = value
+		case "partition":
+			if !nsNameRe.MatchString(value) {
+				return p, fmt.Errorf("invalid partition: %q", value)
+			}
+			p.partition = value
+		case "node-meta":
+			i := strings.Index(value, ":")
+			if i <= 0 {
+				return p, fmt.Errorf("invalid node-meta: %q", value)
+			}
+			if p.nodeMeta == nil {
+				p.nodeMeta = make(map[string]string)
+			}
+			p.nodeMeta[value[:i]] = value[i+1:]
+		default:
+			return p, fmt.Errorf("invalid parameter: %q", param)
+		}
+	}
+	return p, nil
+}
+
+// only returns an error if any parameters other than ns and partition are
+// set, for queries of endpoints that don't support node-meta or filter.
+func (p queryParams) only() error {
+	switch {
+	case len(p.nodeMeta) > 0:
+		return fmt.Errorf("node-meta not supported")
+	case p.filter != "":
+		return fmt.Errorf("filter not supported")
+	}
+	return nil
+}
+
+// String returns the parameters in a stable order, including the leading
+// "?", or an empty string if there are none.
+func (p queryParams) String() string {
+	var parts []string
+	if p.ns != "" {
+		parts = append(parts, "ns="+p.ns)
+	}
+	if p.partition != "" {
+		parts = append(parts, "partition="+p.partition)
+	}
+	for _, k := range sortedKeys(p.nodeMeta) {
+		parts = append(parts, "node-meta="+k+":"+p.nodeMeta[k])
+	}
+	if p.filter != "" {
+		parts = append(parts, "filter="+p.filter)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(parts, "&")
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
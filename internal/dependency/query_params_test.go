@@ -0,0 +1,176 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQueryParams(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  queryParams
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			queryParams{},
+			false,
+		},
+		{
+			"ns",
+			"ns=team-a",
+			queryParams{ns: "team-a"},
+			false,
+		},
+		{
+			"partition",
+			"partition=eu&ns=team-a",
+			queryParams{ns: "team-a", partition: "eu"},
+			false,
+		},
+		{
+			"node_meta",
+			"node-meta=rack:r1&node-meta=zone:a",
+			queryParams{
+				nodeMeta: map[string]string{"rack": "r1", "zone": "a"},
+			},
+			false,
+		},
+		{
+			"node_meta_colon_value",
+			"node-meta=url:http://x",
+			queryParams{
+				nodeMeta: map[string]string{"url": "http://x"},
+			},
+			false,
+		},
+		{
+			"filter",
+			`ns=team-a&filter=Service.Meta.version == "2" and "web" in Service.Tags`,
+			queryParams{
+				ns:     "team-a",
+				filter: `Service.Meta.version == "2" and "web" in Service.Tags`,
+			},
+			false,
+		},
+		{
+			"bad_node_meta",
+			"node-meta=rack",
+			queryParams{},
+			true,
+		},
+		{
+			"bad_ns",
+			"ns=a b",
+			queryParams{},
+			true,
+		},
+		{
+			"bad_partition",
+			"partition=a/b",
+			queryParams{},
+			true,
+		},
+		{
+			"empty_filter",
+			"filter=",
+			queryParams{},
+			true,
+		},
+		{
+			"unknown",
+			"ns=a&index=1",
+			queryParams{},
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := parseQueryParams(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+			if tc.err {
+				return
+			}
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestQueryParams_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    queryParams
+		exp  string
+	}{
+		{
+			"empty",
+			queryParams{},
+			"",
+		},
+		{
+			"all",
+			queryParams{
+				ns:        "team-a",
+				partition: "eu",
+				nodeMeta:  map[string]string{"zone": "a", "rack": "r1"},
+				filter:    `Node.Datacenter == "dc1"`,
+			},
+			`?ns=team-a&partition=eu&node-meta=rack:r1&node-meta=zone:a&filter=Node.Datacenter == "dc1"`,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.exp, tc.i.String())
+		})
+	}
+}
+
+func TestQueryParams_only(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		err  bool
+	}{
+		{
+			"ns_partition",
+			"ns=team-a&partition=eu",
+			false,
+		},
+		{
+			"node_meta",
+			"node-meta=rack:r1",
+			true,
+		},
+		{
+			"filter",
+			"filter=Key != \"\"",
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			p, err := parseQueryParams(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.only(); (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+		})
+	}
+}
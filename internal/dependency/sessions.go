@@ -0,0 +1,152 @@
+package dependency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Ensure implements
+	_ isDependency = (*SessionsQuery)(nil)
+
+	// SessionsQueryRe is the regular expression to use. Without a node name
+	// all the sessions are returned.
+	SessionsQueryRe = regexp.MustCompile(`\A(` + nodeNameRe + `)?` + dcRe + `\z`)
+)
+
+func init() {
+	gob.Register([]*dep.Session{})
+}
+
+// SessionsQuery is the representation of the sessions in Consul, either all
+// of them or those of a node.
+type SessionsQuery struct {
+	isConsul
+	stopCh chan struct{}
+
+	dc   string
+	node string
+	opts QueryOptions
+}
+
+// NewSessionsQuery parses a string into a sessions dependency. The format is
+// [node][@dc].
+func NewSessionsQuery(s string) (*SessionsQuery, error) {
+	if !SessionsQueryRe.MatchString(s) {
+		return nil, fmt.Errorf("session.list: invalid format: %q", s)
+	}
+
+	m := regexpMatch(SessionsQueryRe, s)
+	return &SessionsQuery{
+		stopCh: make(chan struct{}, 1),
+		dc:     m["dc"],
+		node:   m["name"],
+	}, nil
+}
+
+// Fetch queries the Consul API defined by the given client and returns a
+// slice of Session objects.
+func (d *SessionsQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	select {
+	case <-d.stopCh:
+		return nil, nil, ErrStopped
+	default:
+	}
+
+	opts := d.opts.Merge(&QueryOptions{
+		Datacenter: d.dc,
+	})
+
+	var entries []*api.SessionEntry
+	var qm *api.QueryMeta
+	var err error
+	if d.node == "" {
+		entries, qm, err = clients.Consul().Session().List(opts.ToConsulOpts())
+	} else {
+		entries, qm, err = clients.Consul().Session().Node(d.node,
+			opts.ToConsulOpts())
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, d.String())
+	}
+
+	sessions := make([]*dep.Session, 0, len(entries))
+	for _, entry := range entries {
+		sessions = append(sessions, session(entry))
+	}
+	sort.Stable(ByNodeThenSessionID(sessions))
+
+	rm := &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}
+
+	return sessions, rm, nil
+}
+
+// CanShare returns a boolean if this dependency is shareable.
+func (d *SessionsQuery) CanShare() bool {
+	return true
+}
+
+// Stop halts the dependency's fetch function.
+func (d *SessionsQuery) Stop() {
+	close(d.stopCh)
+}
+
+// String returns the human-friendly version of this dependency.
+func (d *SessionsQuery) String() string {
+	name := d.node
+	if d.dc != "" {
+		name = name + "@" + d.dc
+	}
+	if name == "" {
+		return "session.list"
+	}
+	return fmt.Sprintf("session.list(%s)", name)
+}
+
+func (d *SessionsQuery) SetOptions(opts QueryOptions) {
+	d.opts = opts
+}
+
+// session converts the API session entry.
+func session(entry *api.SessionEntry) *dep.Session {
+	serviceChecks := make([]string, 0, len(entry.ServiceChecks))
+	for _, c := range entry.ServiceChecks {
+		serviceChecks = append(serviceChecks, c.ID)
+	}
+
+	return &dep.Session{
+		ID:            entry.ID,
+		Name:          entry.Name,
+		Node:          entry.Node,
+		Namespace:     entry.Namespace,
+		Behavior:      entry.Behavior,
+		TTL:           entry.TTL,
+		LockDelay:     entry.LockDelay,
+		Checks:        entry.Checks,
+		NodeChecks:    entry.NodeChecks,
+		ServiceChecks: serviceChecks,
+		CreateIndex:   entry.CreateIndex,
+	}
+}
+
+// ByNodeThenSessionID is a sortable slice of Session
+type ByNodeThenSessionID []*dep.Session
+
+// Len, Swap, and Less are used to implement the sort.Sort interface.
+func (s ByNodeThenSessionID) Len() int      { return len(s) }
+func (s ByNodeThenSessionID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByNodeThenSessionID) Less(i, j int) bool {
+	if s[i].Node == s[j].Node {
+		return s[i].ID < s[j].ID
+	}
+	return s[i].Node < s[j].Node
+}
@@ -0,0 +1,177 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSessionsQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  *SessionsQuery
+		err  bool
+	}{
+		{
+			"empty",
+			"",
+			&SessionsQuery{},
+			false,
+		},
+		{
+			"dc_only",
+			"@dc1",
+			&SessionsQuery{
+				dc: "dc1",
+			},
+			false,
+		},
+		{
+			"node",
+			"node1",
+			&SessionsQuery{
+				node: "node1",
+			},
+			false,
+		},
+		{
+			"node_dc",
+			"node1.bar.com@dc1",
+			&SessionsQuery{
+				node: "node1.bar.com",
+				dc:   "dc1",
+			},
+			false,
+		},
+		{
+			"bad",
+			"!4d",
+			nil,
+			true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			act, err := NewSessionsQuery(tc.i)
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+
+			if act != nil {
+				act.stopCh = nil
+			}
+
+			assert.Equal(t, tc.exp, act)
+		})
+	}
+}
+
+func TestSessionsQuery_Fetch(t *testing.T) {
+	t.Parallel()
+
+	id, _, err := testClients.Consul().Session().CreateNoChecks(
+		&api.SessionEntry{
+			Name:     "sessions-test",
+			Behavior: api.SessionBehaviorDelete,
+		}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClients.Consul().Session().Destroy(id, nil)
+
+	cases := []struct {
+		name string
+		i    string
+		exp  bool
+	}{
+		{
+			"all",
+			"",
+			true,
+		},
+		{
+			"node",
+			testConsul.Config.NodeName,
+			true,
+		},
+		{
+			"unknown_node",
+			"not_a_real_node",
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewSessionsQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, _, err := d.Fetch(testClients)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var found *dep.Session
+			for _, s := range act.([]*dep.Session) {
+				if s.ID == id {
+					found = s
+				}
+			}
+
+			if !tc.exp {
+				assert.Nil(t, found)
+				return
+			}
+			if assert.NotNil(t, found) {
+				assert.Equal(t, "sessions-test", found.Name)
+				assert.Equal(t, testConsul.Config.NodeName, found.Node)
+				assert.Equal(t, "delete", found.Behavior)
+			}
+		})
+	}
+}
+
+func TestSessionsQuery_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    string
+		exp  string
+	}{
+		{
+			"empty",
+			"",
+			"session.list",
+		},
+		{
+			"dc_only",
+			"@dc1",
+			"session.list(@dc1)",
+		},
+		{
+			"node_dc",
+			"node1@dc1",
+			"session.list(node1@dc1)",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			d, err := NewSessionsQuery(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.exp, d.String())
+		})
+	}
+}
@@ -223,6 +223,19 @@ func updateSecret(ours *dep.Secret, theirs *api.Secret) {
 	}
 }
 
+// VaultKVPath returns the API path for the KV secret at path, adding the
+// "data" prefix for KV v2 mounts, and whether the mount is KV v2.
+func VaultKVPath(client *api.Client, path string) (string, bool, error) {
+	mountPath, v2, err := isKVv2(client, path)
+	switch {
+	case err != nil:
+		return "", false, err
+	case v2:
+		return addPrefixToVKVPath(path, mountPath, "data"), true, nil
+	}
+	return path, false, nil
+}
+
 func isKVv2(client *api.Client, path string) (string, bool, error) {
 	// We don't want to use a wrapping call here so save any custom value and
 	// restore after
@@ -0,0 +1,299 @@
+package hcat
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	rootcerts "github.com/hashicorp/go-rootcerts"
+	"github.com/pkg/errors"
+)
+
+// Kubernetes object kinds supported by the KubernetesRenderer.
+const (
+	KubernetesSecret    = "Secret"
+	KubernetesConfigMap = "ConfigMap"
+)
+
+// Paths of the service account files mounted in Kubernetes pods, used as
+// defaults when running in the cluster.
+const (
+	k8sServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	k8sTokenFile         = k8sServiceAccountDir + "/token"
+	k8sCACertFile        = k8sServiceAccountDir + "/ca.crt"
+	k8sNamespaceFile     = k8sServiceAccountDir + "/namespace"
+)
+
+// KubernetesRenderer renders the template text to a key of a Kubernetes
+// Secret or ConfigMap using the Kubernetes API. The object is created if it
+// doesn't exist, other keys of an existing object are left as is.
+type KubernetesRenderer struct {
+	client    *http.Client
+	address   string
+	token     string
+	tokenFile string
+	kind      string
+	namespace string
+	name      string
+	key       string
+}
+
+// check for interface compliance
+var _ Renderer = (*KubernetesRenderer)(nil)
+
+// KubernetesRendererInput is the input structure for NewKubernetesRenderer.
+//
+// When Address is empty the renderer is configured to run in the cluster,
+// using the pod's service account for the token, CA certificate and default
+// namespace.
+type KubernetesRendererInput struct {
+	// Kind is the kind of object to render to, KubernetesSecret or
+	// KubernetesConfigMap
+	Kind string
+	// Namespace of the object (default "default", or the pod's namespace
+	// in the cluster)
+	Namespace string
+	// Name of the object
+	Name string
+	// Key in the object's data the contents are rendered to
+	Key string
+
+	// Address of the Kubernetes API server (eg. https://10.0.0.1:443)
+	Address string
+	// Token is the bearer token used to authenticate
+	Token string
+	// TokenFile is read for the bearer token on each render, allowing it to
+	// be rotated. Token takes precedence.
+	TokenFile string
+	// CACert is the path to the CA certificate of the API server
+	CACert string
+	// HttpClient, if set, is used instead of creating one
+	HttpClient *http.Client
+}
+
+// NewKubernetesRenderer returns a new KubernetesRenderer.
+func NewKubernetesRenderer(i KubernetesRendererInput) (*KubernetesRenderer, error) {
+	switch {
+	case i.Kind != KubernetesSecret && i.Kind != KubernetesConfigMap:
+		return nil, fmt.Errorf("kubernetes renderer: invalid kind %q", i.Kind)
+	case i.Name == "":
+		return nil, errors.New("kubernetes renderer: missing name")
+	case i.Key == "":
+		return nil, errors.New("kubernetes renderer: missing key")
+	}
+
+	// in cluster defaults
+	if i.Address == "" {
+		host := os.Getenv("KUBERNETES_SERVICE_HOST")
+		port := os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("kubernetes renderer: missing address " +
+				"and not running in a cluster")
+		}
+		i.Address = "https://" + strings.TrimSuffix(host, "/") + ":" + port
+		if i.Token == "" && i.TokenFile == "" {
+			i.TokenFile = k8sTokenFile
+		}
+		if i.CACert == "" {
+			i.CACert = k8sCACertFile
+		}
+		if i.Namespace == "" {
+			if ns, err := ioutil.ReadFile(k8sNamespaceFile); err == nil {
+				i.Namespace = strings.TrimSpace(string(ns))
+			}
+		}
+	}
+	if i.Namespace == "" {
+		i.Namespace = "default"
+	}
+
+	client := i.HttpClient
+	if client == nil {
+		tlsConfig := &tls.Config{}
+		if i.CACert != "" {
+			err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{
+				CAFile: i.CACert,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "kubernetes renderer: configuring TLS")
+			}
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client = &http.Client{Transport: transport}
+	}
+
+	return &KubernetesRenderer{
+		client:    client,
+		address:   strings.TrimSuffix(i.Address, "/"),
+		token:     i.Token,
+		tokenFile: i.TokenFile,
+		kind:      i.Kind,
+		namespace: i.Namespace,
+		name:      i.Name,
+		key:       i.Key,
+	}, nil
+}
+
+// k8sObject is the subset of a Secret or ConfigMap used by the renderer.
+type k8sObject struct {
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Metadata   k8sObjectMeta     `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+type k8sObjectMeta struct {
+	Name            string `json:"name,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// Render writes the contents to the object's key, creating the object if
+// needed. If the key already has the contents nothing is updated.
+func (r *KubernetesRenderer) Render(contents []byte) (RenderResult, error) {
+	value, err := r.encode(contents)
+	if err != nil {
+		return RenderResult{}, err
+	}
+
+	var existing k8sObject
+	found, err := r.do(http.MethodGet, r.objectPath(), "", nil, &existing)
+	if err != nil {
+		return RenderResult{}, errors.Wrap(err, "failed reading object")
+	}
+
+	if !found {
+		obj := k8sObject{
+			APIVersion: "v1",
+			Kind:       r.kind,
+			Metadata:   k8sObjectMeta{Name: r.name, Namespace: r.namespace},
+			Data:       map[string]string{r.key: value},
+		}
+		if _, err := r.do(http.MethodPost, r.collectionPath(),
+			"application/json", obj, nil); err != nil {
+			return RenderResult{}, errors.Wrap(err, "failed creating object")
+		}
+		return RenderResult{
+			DidRender:   true,
+			WouldRender: true,
+		}, nil
+	}
+
+	if old, ok := existing.Data[r.key]; ok && old == value {
+		return RenderResult{
+			DidRender:   false,
+			WouldRender: true,
+		}, nil
+	}
+
+	// the resource version makes the patch fail if the object was changed
+	// since it was read
+	patch := k8sObject{
+		Metadata: k8sObjectMeta{ResourceVersion: existing.Metadata.ResourceVersion},
+		Data:     map[string]string{r.key: value},
+	}
+	if _, err := r.do(http.MethodPatch, r.objectPath(),
+		"application/merge-patch+json", patch, nil); err != nil {
+		return RenderResult{}, errors.Wrap(err, "failed updating object")
+	}
+
+	return RenderResult{
+		DidRender:   true,
+		WouldRender: true,
+	}, nil
+}
+
+// encode returns the contents as the object's data value. Secret data is
+// base64 encoded, ConfigMap data must be valid UTF-8.
+func (r *KubernetesRenderer) encode(contents []byte) (string, error) {
+	if r.kind == KubernetesSecret {
+		return base64.StdEncoding.EncodeToString(contents), nil
+	}
+	if !utf8.Valid(contents) {
+		return "", errors.New("configmap contents must be valid UTF-8")
+	}
+	return string(contents), nil
+}
+
+func (r *KubernetesRenderer) collectionPath() string {
+	resource := "secrets"
+	if r.kind == KubernetesConfigMap {
+		resource = "configmaps"
+	}
+	return fmt.Sprintf("/api/v1/namespaces/%s/%s",
+		url.PathEscape(r.namespace), resource)
+}
+
+func (r *KubernetesRenderer) objectPath() string {
+	return r.collectionPath() + "/" + url.PathEscape(r.name)
+}
+
+// do makes the API request, encoding the body and decoding the response into
+// out when given. Returns false if the object was not found.
+func (r *KubernetesRenderer) do(method, path, contentType string,
+	body, out interface{}) (bool, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return false, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, r.address+path, reqBody)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	token := r.token
+	if token == "" && r.tokenFile != "" {
+		b, err := ioutil.ReadFile(r.tokenFile)
+		if err != nil {
+			return false, errors.Wrap(err, "reading token file")
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && method == http.MethodGet:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		// the API returns a Status object describing the error
+		var status struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&status) // ignore error
+		return false, fmt.Errorf("unexpected response code %d: %s",
+			resp.StatusCode, status.Message)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return false, errors.Wrap(err, "decoding response")
+		}
+	}
+	return true, nil
+}
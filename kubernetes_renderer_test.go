@@ -0,0 +1,200 @@
+package hcat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeK8sAPI is a minimal fake of the Kubernetes API for Secrets and
+// ConfigMaps.
+type fakeK8sAPI struct {
+	sync.Mutex
+	token   string
+	objects map[string]*k8sObject // by request path
+	version int
+	writes  int
+}
+
+func newFakeK8sAPI(token string) (*fakeK8sAPI, *httptest.Server) {
+	api := &fakeK8sAPI{token: token, objects: make(map[string]*k8sObject)}
+	return api, httptest.NewServer(api)
+}
+
+func (f *fakeK8sAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	status := func(code int, msg string) {
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]string{"message": msg})
+	}
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		status(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		obj, ok := f.objects[r.URL.Path]
+		if !ok {
+			status(http.StatusNotFound, "not found")
+			return
+		}
+		json.NewEncoder(w).Encode(obj)
+	case http.MethodPost:
+		var obj k8sObject
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			status(http.StatusBadRequest, err.Error())
+			return
+		}
+		path := r.URL.Path + "/" + obj.Metadata.Name
+		if _, ok := f.objects[path]; ok {
+			status(http.StatusConflict, "already exists")
+			return
+		}
+		f.version++
+		f.writes++
+		obj.Metadata.ResourceVersion = strconv.Itoa(f.version)
+		f.objects[path] = &obj
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(obj)
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			status(http.StatusUnsupportedMediaType, "bad content type")
+			return
+		}
+		obj, ok := f.objects[r.URL.Path]
+		if !ok {
+			status(http.StatusNotFound, "not found")
+			return
+		}
+		var patch k8sObject
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			status(http.StatusBadRequest, err.Error())
+			return
+		}
+		if rv := patch.Metadata.ResourceVersion; rv != "" &&
+			rv != obj.Metadata.ResourceVersion {
+			status(http.StatusConflict, "the object has been modified")
+			return
+		}
+		for k, v := range patch.Data {
+			obj.Data[k] = v
+		}
+		f.version++
+		f.writes++
+		obj.Metadata.ResourceVersion = strconv.Itoa(f.version)
+		json.NewEncoder(w).Encode(obj)
+	default:
+		status(http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func TestKubernetesRenderer(t *testing.T) {
+	render := func(t *testing.T, r *KubernetesRenderer, contents string, did bool) {
+		t.Helper()
+		rr, err := r.Render([]byte(contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender != did || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+	}
+
+	t.Run("secret", func(t *testing.T) {
+		api, srv := newFakeK8sAPI("token")
+		defer srv.Close()
+		r, err := NewKubernetesRenderer(KubernetesRendererInput{
+			Kind: KubernetesSecret, Namespace: "ns", Name: "creds", Key: "app.conf",
+			Address: srv.URL, Token: "token",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		render(t, r, "one", true)
+		render(t, r, "one", false)
+		render(t, r, "two", true)
+
+		obj := api.objects["/api/v1/namespaces/ns/secrets/creds"]
+		if obj == nil || obj.Kind != "Secret" {
+			t.Fatalf("secret not created: %v", api.objects)
+		}
+		v, err := base64.StdEncoding.DecodeString(obj.Data["app.conf"])
+		if err != nil || string(v) != "two" {
+			t.Fatalf("bad secret data: %q, %v", v, err)
+		}
+		if api.writes != 2 {
+			t.Errorf("expected 2 writes, got %d", api.writes)
+		}
+	})
+	t.Run("configmap-keeps-other-keys", func(t *testing.T) {
+		api, srv := newFakeK8sAPI("token")
+		defer srv.Close()
+		path := "/api/v1/namespaces/default/configmaps/cfg"
+		api.objects[path] = &k8sObject{
+			Kind:     "ConfigMap",
+			Metadata: k8sObjectMeta{Name: "cfg", ResourceVersion: "1"},
+			Data:     map[string]string{"other": "keep"},
+		}
+		r, err := NewKubernetesRenderer(KubernetesRendererInput{
+			Kind: KubernetesConfigMap, Name: "cfg", Key: "app.conf",
+			Address: srv.URL, Token: "token",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		render(t, r, "one", true)
+		render(t, r, "one", false)
+
+		data := api.objects[path].Data
+		if data["app.conf"] != "one" || data["other"] != "keep" {
+			t.Fatalf("bad configmap data: %v", data)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, srv := newFakeK8sAPI("token")
+		defer srv.Close()
+		r, err := NewKubernetesRenderer(KubernetesRendererInput{
+			Kind: KubernetesConfigMap, Name: "cfg", Key: "k",
+			Address: srv.URL, Token: "wrong",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr, err := r.Render([]byte("one"))
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Fatalf("expected unauthorized error, got %v", err)
+		}
+		if rr.DidRender || rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+
+		r.token = "token"
+		if _, err := r.Render([]byte{0xff}); err == nil {
+			t.Fatal("expected invalid UTF-8 error")
+		}
+	})
+	t.Run("input", func(t *testing.T) {
+		cases := []KubernetesRendererInput{
+			{Kind: "Pod", Name: "n", Key: "k", Address: "http://x"},
+			{Kind: KubernetesSecret, Key: "k", Address: "http://x"},
+			{Kind: KubernetesSecret, Name: "n", Address: "http://x"},
+		}
+		for _, i := range cases {
+			if _, err := NewKubernetesRenderer(i); err == nil {
+				t.Errorf("expected error for %#v", i)
+			}
+		}
+	})
+}
@@ -34,7 +34,7 @@ func testConsulSetup() (string, func()) {
 	if err != nil {
 		os.Stderr = origStderr
 	}
-	consul, err := testutil.NewTestServerConfig(
+	consul, err := testutil.NewTestServerConfigT(testingTB{"hcat"},
 		func(c *testutil.TestServerConfig) {
 			c.LogLevel = "error"
 			c.Stdout = ioutil.Discard
@@ -46,3 +46,12 @@ func testConsulSetup() (string, func()) {
 	os.Stderr = origStderr
 	return consul.HTTPAddr, func() { consul.Stop() }
 }
+
+// testingTB satisfies testutil.TestingTB to start the test server in
+// TestMain, where there is no *testing.T.
+type testingTB struct{ name string }
+
+func (testingTB) Cleanup(func())              {}
+func (testingTB) Failed() bool                { return false }
+func (testingTB) Logf(string, ...interface{}) {}
+func (tb testingTB) Name() string             { return tb.name }
@@ -0,0 +1,89 @@
+package hcat
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// MemoryRenderer keeps the last N renders in memory. Useful for tests and for
+// serving the rendered contents from within a program.
+type MemoryRenderer struct {
+	size int
+
+	mu      sync.RWMutex
+	renders []Rendered
+}
+
+// check for interface compliance
+var _ Renderer = (*MemoryRenderer)(nil)
+
+// Rendered is a render kept by the MemoryRenderer.
+type Rendered struct {
+	// Contents are the rendered contents
+	Contents []byte
+	// Time is when the contents were rendered
+	Time time.Time
+}
+
+// MemoryRendererInput is the input structure for NewMemoryRenderer.
+type MemoryRendererInput struct {
+	// Size is the number of renders kept (default 1)
+	Size int
+}
+
+// NewMemoryRenderer returns a new MemoryRenderer.
+func NewMemoryRenderer(i MemoryRendererInput) *MemoryRenderer {
+	size := i.Size
+	if size < 1 {
+		size = 1
+	}
+	return &MemoryRenderer{size: size}
+}
+
+// Render keeps a copy of the contents, dropping the oldest render if there
+// are more than the size kept. Unchanged contents are reported as would
+// render but did not, and are not kept again.
+func (r *MemoryRenderer) Render(contents []byte) (RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.renders); n > 0 && bytes.Equal(r.renders[n-1].Contents, contents) {
+		return RenderResult{
+			DidRender:   false,
+			WouldRender: true,
+		}, nil
+	}
+
+	r.renders = append(r.renders, Rendered{
+		Contents: append([]byte{}, contents...),
+		Time:     time.Now(),
+	})
+	if len(r.renders) > r.size {
+		r.renders = append(r.renders[:0], r.renders[len(r.renders)-r.size:]...)
+	}
+
+	return RenderResult{
+		DidRender:   true,
+		WouldRender: true,
+	}, nil
+}
+
+// Last returns the most recent render, false if nothing has been rendered.
+func (r *MemoryRenderer) Last() (Rendered, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.renders) == 0 {
+		return Rendered{}, false
+	}
+	return r.renders[len(r.renders)-1], true
+}
+
+// Renders returns the renders kept, oldest first.
+func (r *MemoryRenderer) Renders() []Rendered {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Rendered{}, r.renders...)
+}
@@ -0,0 +1,62 @@
+package hcat
+
+import (
+	"testing"
+)
+
+func TestMemoryRenderer(t *testing.T) {
+	t.Run("last-n", func(t *testing.T) {
+		r := NewMemoryRenderer(MemoryRendererInput{Size: 2})
+		if _, ok := r.Last(); ok {
+			t.Fatal("expected no renders")
+		}
+		for _, c := range []string{"a", "b", "b", "c"} {
+			if _, err := r.Render([]byte(c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		renders := r.Renders()
+		if len(renders) != 2 ||
+			string(renders[0].Contents) != "b" ||
+			string(renders[1].Contents) != "c" {
+			t.Fatalf("bad renders: %q", renders)
+		}
+		if renders[0].Time.After(renders[1].Time) || renders[1].Time.IsZero() {
+			t.Errorf("bad render times: %v, %v", renders[0].Time, renders[1].Time)
+		}
+		last, ok := r.Last()
+		if !ok || string(last.Contents) != "c" {
+			t.Fatalf("bad last render: %q", last.Contents)
+		}
+	})
+	t.Run("results", func(t *testing.T) {
+		r := NewMemoryRenderer(MemoryRendererInput{})
+		for _, tc := range []struct {
+			contents string
+			did      bool
+		}{{"a", true}, {"a", false}, {"b", true}} {
+			rr, err := r.Render([]byte(tc.contents))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rr.DidRender != tc.did || !rr.WouldRender {
+				t.Fatalf("Bad render results; would: %v, did: %v",
+					rr.WouldRender, rr.DidRender)
+			}
+		}
+		if n := len(r.Renders()); n != 1 {
+			t.Fatalf("expected 1 render kept, got %d", n)
+		}
+	})
+	t.Run("copies-contents", func(t *testing.T) {
+		r := NewMemoryRenderer(MemoryRendererInput{})
+		contents := []byte("foo")
+		if _, err := r.Render(contents); err != nil {
+			t.Fatal(err)
+		}
+		contents[0] = 'b'
+		if last, _ := r.Last(); string(last.Contents) != "foo" {
+			t.Fatalf("contents not copied: %q", last.Contents)
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package hcat
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// sectionMarker starts the marker that the section template function emits
+// to begin a named output section. NUL bytes are used so it won't clash with
+// any real template output.
+const sectionMarker = "\x00hcat-section:"
+
+// sectionManifest is the file in the renderer's directory listing the
+// sections of the last render, one per line, so files of sections removed
+// since are cleaned up across restarts.
+const sectionManifest = ".hcat-sections"
+
+// SectionFunc is the "section" template function. It starts a new named
+// output section for the MultiFileRenderer, which writes everything up to the
+// next section to the file with that name (relative to its Dir).
+//
+//	{{ range service "web" }}{{ section (print .Node ".pem") }}...{{ end }}
+//
+// The marker is typed as HTML so templates using EscapeHTML pass it through
+// unescaped. It must be used outside of any HTML tag, attribute or script.
+func SectionFunc(name string) htmltemplate.HTML {
+	return htmltemplate.HTML(sectionMarker + name + "\x00")
+}
+
+// MultiFileRenderer renders the named output sections of a template, marked
+// with the section template function, each to its own file in a directory.
+//
+// All the files of a render are written together or not at all. Files
+// written by a prior render whose sections are no longer in the output are
+// removed. The sections rendered are listed in a manifest file in the
+// directory, so this holds across restarts of the renderer.
+type MultiFileRenderer struct {
+	dir   string
+	attrs fileAttrs
+
+	mu sync.Mutex
+	// files are the (cleaned, relative) section names of the last render,
+	// loaded from the manifest on creation
+	files map[string]struct{}
+}
+
+// check for interface compliance
+var _ Renderer = (*MultiFileRenderer)(nil)
+
+// MultiFileRendererInput is the input structure for NewMultiFileRenderer.
+type MultiFileRendererInput struct {
+	// Dir is the directory the section files are written to. Section names
+	// are paths relative to it and cannot refer outside of it. The manifest
+	// of the rendered sections, .hcat-sections, is also kept in it.
+	Dir string
+	// CreateDestDirs causes missing directories on the section paths to be
+	// created
+	CreateDestDirs bool
+	// DirPerms sets the mode of directories created by CreateDestDirs
+	// (default 0755)
+	DirPerms os.FileMode
+	// Perms sets the mode of the files
+	Perms os.FileMode
+	// User sets the owner of the files, see FileRendererInput.User
+	User string
+	// Group sets the group of the files
+	Group string
+}
+
+// NewMultiFileRenderer returns a new MultiFileRenderer. The sections of prior
+// renders are read from the manifest in Dir, if there is one.
+func NewMultiFileRenderer(i MultiFileRendererInput) *MultiFileRenderer {
+	dirPerms := i.DirPerms
+	if dirPerms == 0 {
+		dirPerms = defaultDirPerms
+	}
+	return &MultiFileRenderer{
+		dir: i.Dir,
+		attrs: fileAttrs{
+			createDestDirs: i.CreateDestDirs,
+			perms:          i.Perms,
+			dirPerms:       dirPerms,
+			user:           i.User,
+			group:          i.Group,
+		},
+		files: readManifest(i.Dir),
+	}
+}
+
+// Render splits the contents into its sections and atomically writes those
+// that changed to their files, removing the files of sections no longer
+// present. If any file fails to be written none are changed.
+func (r *MultiFileRenderer) Render(contents []byte) (RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dir == "" {
+		return RenderResult{}, errMissingDest
+	}
+	sections, err := parseSections(contents)
+	if err != nil {
+		return RenderResult{}, err
+	}
+
+	var ops []*fileOp
+	defer func() {
+		for _, op := range ops {
+			op.cleanup()
+		}
+	}()
+
+	// stage all the changed files
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(r.dir, name)
+		existing, err := ioutil.ReadFile(path)
+		fileExists := !os.IsNotExist(err)
+		if err != nil && fileExists {
+			return RenderResult{}, errors.Wrap(err, "failed reading file")
+		}
+		if fileExists && bytes.Equal(existing, sections[name]) {
+			continue
+		}
+		tmp, err := stageWrite(path, sections[name], r.attrs)
+		if err != nil {
+			return RenderResult{}, errors.Wrapf(err, "failed writing %q", name)
+		}
+		ops = append(ops, &fileOp{path: path, tmp: tmp})
+	}
+
+	// and the stale ones
+	for name := range r.files {
+		if _, ok := sections[name]; !ok {
+			ops = append(ops, &fileOp{path: filepath.Join(r.dir, name)})
+		}
+	}
+	didRender := len(ops) > 0
+
+	// the manifest is updated along with the files
+	manifest := filepath.Join(r.dir, sectionManifest)
+	existing, err := ioutil.ReadFile(manifest)
+	if err != nil && !os.IsNotExist(err) {
+		return RenderResult{}, errors.Wrap(err, "failed reading manifest")
+	}
+	if list := manifestContents(names); !bytes.Equal(existing, list) {
+		tmp, err := stageWrite(manifest, list, r.attrs)
+		if err != nil {
+			return RenderResult{}, errors.Wrap(err, "failed writing manifest")
+		}
+		ops = append(ops, &fileOp{path: manifest, tmp: tmp})
+	}
+
+	for i, op := range ops {
+		if err := op.apply(); err != nil {
+			for _, done := range ops[:i] {
+				done.undo() // ignore error, best effort
+			}
+			return RenderResult{}, errors.Wrapf(err, "failed updating %q", op.path)
+		}
+	}
+
+	r.files = make(map[string]struct{}, len(sections))
+	for name := range sections {
+		r.files[name] = struct{}{}
+	}
+
+	return RenderResult{
+		DidRender:   didRender,
+		WouldRender: true,
+	}, nil
+}
+
+// manifestContents returns the manifest listing the (sorted) section names.
+func manifestContents(names []string) []byte {
+	var b bytes.Buffer
+	for _, name := range names {
+		b.WriteString(filepath.ToSlash(name))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// readManifest returns the section names listed in the manifest in dir. A
+// missing or unreadable manifest is empty and invalid names are skipped, so
+// nothing outside of dir is ever removed.
+func readManifest(dir string) map[string]struct{} {
+	files := make(map[string]struct{})
+	if dir == "" {
+		return files
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, sectionManifest))
+	if err != nil {
+		return files
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		if name, err := sectionName(line); err == nil {
+			files[name] = struct{}{}
+		}
+	}
+	return files
+}
+
+// parseSections splits the rendered contents into its sections by name.
+// Anything before the first section must be whitespace.
+func parseSections(contents []byte) (map[string][]byte, error) {
+	parts := bytes.Split(contents, []byte(sectionMarker))
+	if len(bytes.TrimSpace(parts[0])) > 0 {
+		return nil, errors.New("content found outside of a section")
+	}
+
+	sections := make(map[string][]byte, len(parts)-1)
+	for _, part := range parts[1:] {
+		end := bytes.IndexByte(part, 0)
+		if end < 0 {
+			return nil, errors.New("malformed section marker")
+		}
+		name, err := sectionName(string(part[:end]))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := sections[name]; ok {
+			return nil, fmt.Errorf("duplicate section %q", name)
+		}
+		sections[name] = part[end+1:]
+	}
+	return sections, nil
+}
+
+// sectionName validates and cleans a section name, which must be a relative
+// path that stays within the renderer's directory.
+func sectionName(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	switch {
+	case name == "", clean == ".":
+		return "", errors.New("empty section name")
+	case clean == sectionManifest:
+		return "", fmt.Errorf("section name %q is reserved", name)
+	case filepath.IsAbs(clean), filepath.VolumeName(clean) != "",
+		clean == "..", strings.HasPrefix(clean, ".."+string(filepath.Separator)):
+		return "", fmt.Errorf("section %q is outside of the directory", name)
+	}
+	return clean, nil
+}
+
+// fileOp replaces the file at path with the staged tmp file, or removes it if
+// tmp is empty, keeping the previous file so the change can be undone.
+type fileOp struct {
+	path string
+	tmp  string
+	// old is a link to the file before the change, if it existed
+	old string
+	// applied is true once the change has been made
+	applied bool
+}
+
+func (o *fileOp) apply() error {
+	if _, err := os.Lstat(o.path); err == nil {
+		old := o.path + ".hcat-old"
+		os.Remove(old) // ignore error, link fails if it exists
+		if err := os.Link(o.path, old); err != nil {
+			return err
+		}
+		o.old = old
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var err error
+	if o.tmp != "" {
+		err = os.Rename(o.tmp, o.path)
+	} else if o.old != "" {
+		err = os.Remove(o.path)
+	}
+	if err != nil {
+		return err
+	}
+	o.applied = true
+	return nil
+}
+
+// undo restores the file to how it was before apply.
+func (o *fileOp) undo() error {
+	if !o.applied {
+		return nil
+	}
+	if o.old != "" {
+		if err := os.Rename(o.old, o.path); err != nil {
+			return err
+		}
+		o.old = ""
+	} else if err := os.Remove(o.path); err != nil {
+		return err
+	}
+	o.applied = false
+	return nil
+}
+
+// cleanup removes any left over temporary files.
+func (o *fileOp) cleanup() {
+	for _, p := range []string{o.tmp, o.old} {
+		if p != "" {
+			os.Remove(p) // ignore error
+		}
+	}
+}
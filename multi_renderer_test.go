@@ -0,0 +1,261 @@
+package hcat
+
+import (
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultiFileRenderer(t *testing.T) {
+	sections := func(kv ...string) []byte {
+		var b strings.Builder
+		for i := 0; i < len(kv); i += 2 {
+			b.WriteString(string(SectionFunc(kv[i])))
+			b.WriteString(kv[i+1])
+		}
+		return []byte(b.String())
+	}
+	checkFiles := func(t *testing.T, dir string, exp map[string]string) {
+		act := make(map[string]string)
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || info.Name() == sectionManifest {
+				return err
+			}
+			c, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, p)
+			act[filepath.ToSlash(rel)] = string(c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(exp) != len(act) {
+			t.Fatalf("\nexp: %v\nact: %v", exp, act)
+		}
+		for k, v := range exp {
+			if act[k] != v {
+				t.Fatalf("\nexp: %v\nact: %v", exp, act)
+			}
+		}
+	}
+	setup := func(t *testing.T) (string, func()) {
+		dir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dir, func() { os.RemoveAll(dir) }
+	}
+
+	t.Run("render-and-cleanup", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		r := NewMultiFileRenderer(MultiFileRendererInput{
+			Dir: dir, CreateDestDirs: true})
+
+		rr, err := r.Render(sections("a.pem", "A", "sub/b.pem", "B"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		checkFiles(t, dir, map[string]string{"a.pem": "A", "sub/b.pem": "B"})
+
+		rr, err = r.Render(sections("a.pem", "A", "sub/b.pem", "B"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+
+		rr, err = r.Render(sections("a.pem", "A2", "c.pem", "C"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender {
+			t.Fatal("expected render")
+		}
+		checkFiles(t, dir, map[string]string{"a.pem": "A2", "c.pem": "C"})
+	})
+
+	t.Run("all-or-nothing", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		r := NewMultiFileRenderer(MultiFileRendererInput{Dir: dir})
+
+		if _, err := r.Render(sections("a", "A", "b", "B")); err != nil {
+			t.Fatal(err)
+		}
+		// missing directory without CreateDestDirs fails to stage
+		_, err := r.Render(sections("a", "A2", "missing/c", "C"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		checkFiles(t, dir, map[string]string{"a": "A", "b": "B"})
+
+		// failed render doesn't forget the files to clean up
+		if _, err := r.Render(sections("a", "A3")); err != nil {
+			t.Fatal(err)
+		}
+		checkFiles(t, dir, map[string]string{"a": "A3"})
+	})
+
+	t.Run("cleanup-after-restart", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		input := MultiFileRendererInput{Dir: dir}
+
+		r := NewMultiFileRenderer(input)
+		if _, err := r.Render(sections("a", "A", "b", "B")); err != nil {
+			t.Fatal(err)
+		}
+
+		// a new renderer picks up the files of the last one from the manifest
+		r = NewMultiFileRenderer(input)
+		rr, err := r.Render(sections("a", "A"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender {
+			t.Fatal("expected render")
+		}
+		checkFiles(t, dir, map[string]string{"a": "A"})
+
+		c, err := ioutil.ReadFile(filepath.Join(dir, sectionManifest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(c) != "a\n" {
+			t.Errorf("bad manifest: %q", c)
+		}
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		if err := ioutil.WriteFile(filepath.Join(dir, "a"), []byte("A"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var ops []*fileOp
+		for _, name := range []string{"a", "b"} {
+			tmp, err := stageWrite(filepath.Join(dir, name), []byte("new"), fileAttrs{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops = append(ops, &fileOp{path: filepath.Join(dir, name), tmp: tmp})
+		}
+		for _, op := range ops {
+			if err := op.apply(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c, err := ioutil.ReadFile(filepath.Join(dir, "b")); err != nil || string(c) != "new" {
+			t.Fatalf("bad contents: %q, %v", c, err)
+		}
+		for i := len(ops) - 1; i >= 0; i-- {
+			if err := ops[i].undo(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, op := range ops {
+			op.cleanup()
+		}
+		checkFiles(t, dir, map[string]string{"a": "A"})
+	})
+
+	t.Run("template", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		r := NewMultiFileRenderer(MultiFileRendererInput{Dir: dir})
+
+		tpl, err := NewTemplate(TemplateInput{
+			Contents: `{{ range $k, $v := . }}{{ section $k }}{{ $v }}{{ end }}`,
+			Data:     map[string]string{"x.conf": "X", "y.conf": "Y"},
+			Renderer: r,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := tpl.Execute(fakeWatcher{NewStore()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tpl.Render(contents); err != nil {
+			t.Fatal(err)
+		}
+		checkFiles(t, dir, map[string]string{"x.conf": "X", "y.conf": "Y"})
+	})
+
+	t.Run("template-escape-html", func(t *testing.T) {
+		dir, cleanup := setup(t)
+		defer cleanup()
+		r := NewMultiFileRenderer(MultiFileRendererInput{Dir: dir})
+
+		tpl, err := NewTemplate(TemplateInput{
+			Contents:   `{{ range $k, $v := . }}{{ section $k }}{{ $v }}{{ end }}`,
+			Data:       map[string]string{"x.html": "<X>", "y.html": "Y&"},
+			Renderer:   r,
+			EscapeHTML: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := tpl.Execute(fakeWatcher{NewStore()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tpl.Render(contents); err != nil {
+			t.Fatal(err)
+		}
+		checkFiles(t, dir,
+			map[string]string{"x.html": "&lt;X&gt;", "y.html": "Y&amp;"})
+	})
+}
+
+func TestParseSections(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents htmltemplate.HTML
+		exp      map[string]string
+		err      bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"whitespace-before", "\n  " + SectionFunc("a") + "A",
+			map[string]string{"a": "A"}, false},
+		{"content-before", "x" + SectionFunc("a") + "A", nil, true},
+		{"clean-name", SectionFunc("./a/../b") + "B",
+			map[string]string{"b": "B"}, false},
+		{"duplicate", SectionFunc("a") + SectionFunc("a"), nil, true},
+		{"empty-name", SectionFunc("") + "A", nil, true},
+		{"parent", SectionFunc("../a") + "A", nil, true},
+		{"absolute", SectionFunc("/etc/passwd") + "A", nil, true},
+		{"malformed", sectionMarker + "a", nil, true},
+		{"reserved", SectionFunc(sectionManifest) + "A", nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			act, err := parseSections([]byte(tc.contents))
+			if (err != nil) != tc.err {
+				t.Fatal(err)
+			}
+			if len(act) != len(tc.exp) {
+				t.Fatalf("\nexp: %v\nact: %q", tc.exp, act)
+			}
+			for k, v := range tc.exp {
+				if string(act[k]) != v {
+					t.Fatalf("\nexp: %v\nact: %q", tc.exp, act)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package hcat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcat/dep"
+)
+
+// MetadataRenderer is implemented by Renderers that record the metadata of
+// their renders, like the FileRenderer's sidecar file. Templates call it with
+// the completed RenderResult after each successful render.
+type MetadataRenderer interface {
+	RenderMetadata(RenderResult) error
+}
+
+// renderMetadata is the JSON representation of a render's metadata.
+type renderMetadata struct {
+	Path         string    `json:"path,omitempty"`
+	Hash         string    `json:"hash,omitempty"`
+	Size         int       `json:"size"`
+	PreviousHash string    `json:"previous_hash,omitempty"`
+	Time         time.Time `json:"time"`
+	Dependencies []string  `json:"dependencies"`
+}
+
+// contentHash returns the hex encoded SHA-256 hash of the contents.
+func contentHash(contents []byte) string {
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:])
+}
+
+// renderState tracks what a template needs to complete its RenderResults;
+// the dependencies used by the last complete execution and the hash of the
+// last contents actually rendered.
+type renderState struct {
+	sync.Mutex
+	deps     []string
+	lastHash string
+}
+
+// setDeps records the dependencies used to produce the contents to render.
+func (s *renderState) setDeps(deps []string) {
+	s.Lock()
+	defer s.Unlock()
+	s.deps = deps
+}
+
+// render calls the renderer with the contents and fills in the result's
+// metadata fields not already set by the renderer.
+func (s *renderState) render(r Renderer, contents []byte) (RenderResult, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	rr, err := r.Render(contents)
+	if err != nil {
+		return rr, err
+	}
+
+	// the hash and size describe the same contents, a renderer setting the
+	// hash has set the size too, even if it is 0
+	if rr.Hash == "" {
+		rr.Hash = contentHash(contents)
+		rr.Size = len(contents)
+	}
+	if rr.PreviousHash == "" {
+		rr.PreviousHash = s.lastHash
+	}
+	if rr.Time.IsZero() {
+		rr.Time = time.Now()
+	}
+	if rr.Dependencies == nil {
+		rr.Dependencies = append([]string{}, s.deps...)
+	}
+	// only contents written replace those rendered before
+	if rr.DidRender {
+		s.lastHash = rr.Hash
+	}
+
+	if mr, ok := r.(MetadataRenderer); ok {
+		if err := mr.RenderMetadata(rr); err != nil {
+			return rr, err
+		}
+	}
+	return rr, nil
+}
+
+// depRecorder wraps a Recaller, recording the IDs of the dependencies
+// recalled through it.
+type depRecorder struct {
+	recaller Recaller
+	mu       sync.Mutex
+	ids      map[string]struct{}
+}
+
+func newDepRecorder(r Recaller) *depRecorder {
+	return &depRecorder{recaller: r, ids: make(map[string]struct{})}
+}
+
+func (r *depRecorder) recall(d dep.Dependency) (interface{}, bool) {
+	r.mu.Lock()
+	r.ids[d.String()] = struct{}{}
+	r.mu.Unlock()
+	return r.recaller(d)
+}
+
+// list returns the recorded dependency IDs, sorted.
+func (r *depRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.ids))
+	for id := range r.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
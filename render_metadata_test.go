@@ -0,0 +1,208 @@
+package hcat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	idep "github.com/hashicorp/hcat/internal/dependency"
+)
+
+func TestRenderMetadata(t *testing.T) {
+	store := func(t *testing.T, kv map[string]string) *Store {
+		st := NewStore()
+		for k, v := range kv {
+			d, err := idep.NewKVGetQuery(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st.Save(d.String(), v)
+		}
+		return st
+	}
+
+	t.Run("template", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path: path, Metadata: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := NewTemplate(TemplateInput{
+			Contents: `{{ key "b" }}{{ key "a" }}{{ key "a" }}`,
+			Renderer: fr,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		render := func(w Watcherer) RenderResult {
+			tpl.Notify(nil)
+			contents, err := tpl.Execute(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr, err := tpl.Render(contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return rr
+		}
+
+		rr := render(fakeWatcher{store(t, map[string]string{"a": "1", "b": "2"})})
+		switch {
+		case !rr.DidRender || !rr.WouldRender:
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		case rr.Hash != contentHash([]byte("211")):
+			t.Errorf("bad hash: %s", rr.Hash)
+		case rr.Size != 3:
+			t.Errorf("bad size: %d", rr.Size)
+		case rr.PreviousHash != "":
+			t.Errorf("bad previous hash: %s", rr.PreviousHash)
+		case rr.Time.IsZero():
+			t.Error("render time not set")
+		case !reflect.DeepEqual(rr.Dependencies, []string{"kv.get(a)", "kv.get(b)"}):
+			t.Errorf("bad dependencies: %v", rr.Dependencies)
+		}
+
+		var md renderMetadata
+		b, err := ioutil.ReadFile(path + metadataSuffix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &md); err != nil {
+			t.Fatal(err)
+		}
+		if md.Path != path || md.Hash != rr.Hash || md.Size != rr.Size ||
+			!md.Time.Equal(rr.Time) ||
+			!reflect.DeepEqual(md.Dependencies, rr.Dependencies) {
+			t.Errorf("bad metadata: %+v", md)
+		}
+
+		prev := rr
+		rr = render(fakeWatcher{store(t, map[string]string{"a": "1", "b": "2"})})
+		if rr.DidRender || rr.PreviousHash != prev.Hash || rr.Hash != prev.Hash {
+			t.Errorf("bad unchanged render result: %+v", rr)
+		}
+		if b2, _ := ioutil.ReadFile(path + metadataSuffix); string(b2) != string(b) {
+			t.Error("metadata rewritten for unchanged render")
+		}
+
+		rr = render(fakeWatcher{store(t, map[string]string{"a": "3", "b": "4"})})
+		if !rr.DidRender || rr.PreviousHash != prev.Hash ||
+			rr.Hash != contentHash([]byte("433")) {
+			t.Errorf("bad changed render result: %+v", rr)
+		}
+		if err := json.Unmarshal(mustRead(t, path+metadataSuffix), &md); err != nil {
+			t.Fatal(err)
+		}
+		if md.Hash != rr.Hash || md.PreviousHash != prev.Hash {
+			t.Errorf("bad metadata: %+v", md)
+		}
+	})
+
+	t.Run("previous-from-template", func(t *testing.T) {
+		m := NewMemoryRenderer(MemoryRendererInput{})
+		tpl, err := NewHCLTemplate(HCLTemplateInput{
+			Contents: `${key("a")}`,
+			Renderer: m,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := fakeWatcher{store(t, map[string]string{"a": "1"})}
+		contents, err := tpl.Execute(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, err := tpl.Render(contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(first.Dependencies, []string{"kv.get(a)"}) {
+			t.Errorf("bad dependencies: %v", first.Dependencies)
+		}
+		second, err := tpl.Render([]byte("2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.PreviousHash != first.Hash {
+			t.Errorf("bad previous hash: %s", second.PreviousHash)
+		}
+	})
+
+	t.Run("no-metadata-file", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+
+		fr, err := NewFileRenderer(FileRendererInput{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := NewTemplate(TemplateInput{
+			Contents: "foo",
+			Renderer: fr,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tpl.Render([]byte("foo")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path + metadataSuffix); !os.IsNotExist(err) {
+			t.Errorf("unexpected metadata file: %v", err)
+		}
+	})
+
+	t.Run("kept-empty-file", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+		path := filepath.Join(outDir, "out")
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path:    path,
+			Compare: func([]byte, []byte) bool { return true },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := NewTemplate(TemplateInput{Renderer: fr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr, err := tpl.Render([]byte("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender || rr.Size != 0 || rr.Hash != contentHash(nil) {
+			t.Errorf("bad result for kept file: %+v", rr)
+		}
+	})
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -2,9 +2,11 @@ package hcat
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -13,6 +15,14 @@ const (
 	// DefaultFilePerms are the default file permissions for files rendered onto
 	// disk when a specific file permission has not already been specified.
 	defaultFilePerms = 0644
+
+	// DefaultDirPerms are the default permissions for the directories created
+	// with CreateDestDirs when specific permissions have not been specified.
+	defaultDirPerms = 0755
+
+	// metadataSuffix is appended to the rendered file's path for the
+	// metadata sidecar file.
+	metadataSuffix = ".meta.json"
 )
 
 var (
@@ -22,43 +32,120 @@ var (
 
 	// ErrMissingDest is the error returned with the destination is empty.
 	errMissingDest = errors.New("missing destination")
+
+	// errValidateEncrypted is the error returned when both Validate and
+	// Encrypter are set, as validation would write the plaintext to disk.
+	errValidateEncrypted = errors.New(
+		"file renderer: validate can't be used with encrypter")
 )
 
 // FileRenderer will handle rendering the template text to a file.
 type FileRenderer struct {
+	path     string
+	attrs    fileAttrs
+	backup   BackupFunc
+	validate ValidateFunc
+	compare  CompareFunc
+	encrypt  Encrypter
+	metadata bool
+}
+
+// fileAttrs are the attributes applied to the rendered file and to the
+// directories created for it.
+type fileAttrs struct {
 	createDestDirs bool
-	path           string
 	perms          os.FileMode
-	backup         BackupFunc
+	dirPerms       os.FileMode
+	user           string
+	group          string
+	selinuxContext string
+	preserveXattrs bool
 }
 
 // check for innterface compliance
 var _ Renderer = (*FileRenderer)(nil)
+var _ MetadataRenderer = (*FileRenderer)(nil)
 
-// NewFileRenderer returns a new FileRenderer.
-func NewFileRenderer(i FileRendererInput) FileRenderer {
+// NewFileRenderer returns a new FileRenderer. It errors if both Validate and
+// Encrypter are set.
+func NewFileRenderer(i FileRendererInput) (FileRenderer, error) {
+	if i.Encrypter != nil && i.Validate != nil {
+		return FileRenderer{}, errValidateEncrypted
+	}
 	backup := i.Backup
 	if backup == nil {
 		backup = func(string) {}
 	}
-	return FileRenderer{
-		createDestDirs: i.CreateDestDirs,
-		path:           i.Path,
-		perms:          i.Perms,
-		backup:         backup,
+	dirPerms := i.DirPerms
+	if dirPerms == 0 {
+		dirPerms = defaultDirPerms
 	}
+	compare := i.Compare
+	if compare == nil {
+		compare = bytes.Equal
+	}
+	return FileRenderer{
+		path: i.Path,
+		attrs: fileAttrs{
+			createDestDirs: i.CreateDestDirs,
+			perms:          i.Perms,
+			dirPerms:       dirPerms,
+			user:           i.User,
+			group:          i.Group,
+			selinuxContext: i.SELinuxContext,
+			preserveXattrs: i.PreserveXattrs,
+		},
+		backup:   backup,
+		validate: i.Validate,
+		compare:  compare,
+		encrypt:  i.Encrypter,
+		metadata: i.Metadata,
+	}, nil
 }
 
 // FileRendererInput is the input structure for NewFileRenderer.
 type FileRendererInput struct {
 	// CreateDestDirs causes missing directories on path to be created
 	CreateDestDirs bool
+	// DirPerms sets the mode of directories created by CreateDestDirs
+	// (default 0755)
+	DirPerms os.FileMode
 	// Path is the full file path to write to
 	Path string
 	// Perms sets the mode of the file
 	Perms os.FileMode
+	// User sets the owner of the file, by name or numeric uid. If User and
+	// Group are empty the ownership of an existing file is preserved.
+	User string
+	// Group sets the group of the file, by name or numeric gid
+	Group string
+	// SELinuxContext sets the SELinux security context of the file
+	// (eg. "system_u:object_r:etc_t:s0"). Linux only.
+	SELinuxContext string
+	// PreserveXattrs copies the extended attributes of an existing file to
+	// the newly rendered one. Linux only.
+	PreserveXattrs bool
 	// Backup causes a backup of the rendered file to be made
 	Backup BackupFunc
+	// Validate is called with a temporary file holding the new contents
+	// before they are written. If it returns an error the file is left
+	// untouched and Render returns a *ValidationError. It can't be used with
+	// Encrypter as the temporary file holds the plaintext, NewFileRenderer
+	// returns an error if both are set.
+	Validate ValidateFunc
+	// Compare is used to compare the existing file's contents with the new
+	// ones, the file is only written if they differ (default bytes.Equal).
+	// See CompareNormalized to ignore whitespace or matching lines.
+	Compare CompareFunc
+	// Encrypter encrypts the contents written to the file, keeping rendered
+	// secrets encrypted at rest. Comparisons are done on the plaintext and
+	// the metadata sidecar leaves out its hashes. Use DecryptFile to read the
+	// file.
+	Encrypter Encrypter
+	// Metadata causes a [filename].meta.json sidecar file to be written next
+	// to the rendered file with the render's hash, size, time and
+	// dependencies (see RenderResult)
+	Metadata bool
 }
 
 // BackupFunc defines the function type passed in to make backups if previously
@@ -77,6 +164,24 @@ type RenderResult struct {
 	// will return false in the event of an error, but will return true in dry
 	// mode or when the template on disk matches the new result.
 	WouldRender bool
+
+	// Hash is the hex encoded SHA-256 hash of the rendered contents.
+	Hash string
+
+	// Size is the size of the rendered contents in bytes. Renderers setting
+	// Hash also set Size.
+	Size int
+
+	// PreviousHash is the hash of the contents replaced by the render, or
+	// those rendered before, if known.
+	PreviousHash string
+
+	// Time is when the contents were rendered.
+	Time time.Time
+
+	// Dependencies are the IDs of the dependencies used to produce the
+	// rendered contents, sorted.
+	Dependencies []string
 }
 
 // Render atomically renders a file contents to disk, returning a result of
@@ -88,26 +193,93 @@ func (r FileRenderer) Render(contents []byte) (RenderResult, error) {
 		return RenderResult{}, errors.Wrap(err, "failed reading file")
 	}
 
-	if bytes.Equal(existing, contents) && fileExists {
+	// compare against the existing plaintext, if it can't be decrypted (eg.
+	// a plaintext file or a changed key) it is replaced
+	comparable := fileExists
+	if fileExists && r.encrypt != nil {
+		if plaintext, err := r.encrypt.Decrypt(existing); err == nil {
+			existing = plaintext
+		} else {
+			comparable = false
+		}
+	}
+
+	var previousHash string
+	if fileExists {
+		previousHash = contentHash(existing)
+	}
+
+	// the contents on disk are kept, so the result describes those
+	if comparable && r.compare(existing, contents) {
 		return RenderResult{
-			DidRender:   false,
-			WouldRender: true,
+			DidRender:    false,
+			WouldRender:  true,
+			Hash:         previousHash,
+			Size:         len(existing),
+			PreviousHash: previousHash,
 		}, nil
 	}
 
+	if r.validate != nil {
+		if err := validate(r.path, contents, r.validate); err != nil {
+			return RenderResult{}, err
+		}
+	}
+
+	if r.encrypt != nil {
+		if contents, err = r.encrypt.Encrypt(contents); err != nil {
+			return RenderResult{}, errors.Wrap(err, "failed encrypting contents")
+		}
+	}
+
 	r.backup(r.path)
 
-	err = atomicWrite(r.path, contents, r.perms, r.createDestDirs)
+	err = atomicWrite(r.path, contents, r.attrs)
 	if err != nil {
 		return RenderResult{}, errors.Wrap(err, "failed writing file")
 	}
 
 	return RenderResult{
-		DidRender:   true,
-		WouldRender: true,
+		DidRender:    true,
+		WouldRender:  true,
+		PreviousHash: previousHash,
 	}, nil
 }
 
+// RenderMetadata writes the render's metadata to the sidecar file, if
+// enabled. It is only rewritten when the file was rendered or if missing.
+func (r FileRenderer) RenderMetadata(rr RenderResult) error {
+	if !r.metadata {
+		return nil
+	}
+	path := r.path + metadataSuffix
+	if _, err := os.Stat(path); err == nil && !rr.DidRender {
+		return nil
+	}
+
+	md := renderMetadata{
+		Path:         r.path,
+		Hash:         rr.Hash,
+		Size:         rr.Size,
+		PreviousHash: rr.PreviousHash,
+		Time:         rr.Time,
+		Dependencies: rr.Dependencies,
+	}
+	// the hashes are of the plaintext, which could be used to confirm
+	// guesses of the encrypted contents
+	if r.encrypt != nil {
+		md.Hash, md.PreviousHash = "", ""
+	}
+	contents, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed encoding metadata")
+	}
+	if err := atomicWrite(path, append(contents, '\n'), r.attrs); err != nil {
+		return errors.Wrap(err, "failed writing metadata")
+	}
+	return nil
+}
+
 // Backup creates a [filename].bak copy, preserving the Mode
 // Provided for convenience (to use as the BackupFunc) and an example.
 func Backup(path string) {
@@ -125,79 +297,125 @@ func Backup(path string) {
 // the template contents to a TempFile on disk, returning if any errors occur.
 //
 // If the parent destination directory does not exist, it will be created
-// automatically with permissions 0755. To use a different permission, create
-// the directory first or use `chmod` in a Command.
+// automatically with the dirPerms permissions (0755 if unset).
 //
 // If the destination path exists, all attempts will be made to preserve the
 // existing file permissions. If those permissions cannot be read, an error is
 // returned. If the file does not exist, it will be created automatically with
-// permissions 0644. To use a different permission, create the destination file
-// first or use `chmod` in a Command.
+// permissions 0644. The perms attribute overrides either case.
+//
+// The file is owned by the user and group attributes when given, otherwise
+// the ownership of an existing file is preserved. Extended attributes of an
+// existing file are copied when preserveXattrs is set, and the SELinux context
+// is set last so it takes precedence over any copied one.
 //
 // If no errors occur, the Tempfile is "renamed" (moved) to the destination
 // path.
-func atomicWrite(
-	path string, contents []byte, perms os.FileMode, createDestDirs bool,
-) error {
+func atomicWrite(path string, contents []byte, attrs fileAttrs) error {
+	tmp, err := stageWrite(path, contents, attrs)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Rename(tmp, path)
+}
+
+// stageWrite does all the work of atomicWrite except the final rename,
+// returning the path of the TempFile ready to be moved to the destination
+// path. The TempFile is removed if any errors occur.
+func stageWrite(path string, contents []byte, attrs fileAttrs) (tmp string, err error) {
 	if path == "" {
-		return errMissingDest
+		return "", errMissingDest
+	}
+
+	dirPerms := attrs.dirPerms
+	if dirPerms == 0 {
+		dirPerms = defaultDirPerms
 	}
 
 	parent := filepath.Dir(path)
 	if _, err := os.Stat(parent); os.IsNotExist(err) {
-		if createDestDirs {
-			if err := os.MkdirAll(parent, 0755); err != nil {
-				return err
+		if attrs.createDestDirs {
+			if err := os.MkdirAll(parent, dirPerms); err != nil {
+				return "", err
 			}
 		} else {
-			return errNoParentDir
+			return "", errNoParentDir
 		}
 	}
 
 	f, err := ioutil.TempFile(parent, "")
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer os.Remove(f.Name())
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
 
 	if _, err := f.Write(contents); err != nil {
-		return err
+		return "", err
 	}
 
 	if err := f.Sync(); err != nil {
-		return err
+		return "", err
 	}
 
 	if err := f.Close(); err != nil {
-		return err
+		return "", err
 	}
 
-	// If the user did not explicitly set permissions, attempt to lookup the
-	// current permissions on the file. If the file does not exist, fall back to
-	// the default. Otherwise, inherit the current permissions.
-	if perms == 0 {
-		currentInfo, err := os.Stat(path)
+	currentInfo, err := os.Stat(path)
+	fileExists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	// Explicit ownership takes precedence, otherwise if the file exists try
+	// to preserve its ownership. Done before chmod as chown can clear the
+	// setuid/setgid bits.
+	if attrs.user != "" || attrs.group != "" {
+		uid, gid, err := lookupOwner(attrs.user, attrs.group)
 		if err != nil {
-			if os.IsNotExist(err) {
-				perms = defaultFilePerms
-			} else {
-				return err
-			}
-		} else {
-			perms = currentInfo.Mode()
+			return "", err
+		}
+		if err := os.Chown(f.Name(), uid, gid); err != nil {
+			return "", err
+		}
+	} else if fileExists {
+		preserveFilePermissions(f.Name(), currentInfo)
+	}
 
-			// The file exists, so try to preserve the ownership as well.
-			preserveFilePermissions(f.Name(), currentInfo)
+	// If the user did not explicitly set permissions, inherit the current
+	// permissions on the file. If the file does not exist, fall back to the
+	// default.
+	perms := attrs.perms
+	if perms == 0 {
+		if fileExists {
+			perms = currentInfo.Mode()
+		} else {
+			perms = defaultFilePerms
 		}
 	}
 
 	if err := os.Chmod(f.Name(), perms); err != nil {
-		return err
+		return "", err
 	}
 
-	if err := os.Rename(f.Name(), path); err != nil {
-		return err
+	if attrs.preserveXattrs && fileExists {
+		if err := copyXattrs(path, f.Name()); err != nil {
+			return "", err
+		}
 	}
 
-	return nil
+	if attrs.selinuxContext != "" {
+		if err := setSELinuxContext(f.Name(), attrs.selinuxContext); err != nil {
+			return "", err
+		}
+	}
+
+	return f.Name(), nil
 }
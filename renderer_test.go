@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"path"
 	"path/filepath"
+	"strconv"
 	"testing"
 )
 
@@ -26,7 +28,8 @@ func TestAtomicWrite(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if err := atomicWrite(outFile.Name(), nil, 0644, true); err != nil {
+		attrs := fileAttrs{perms: 0644, createDestDirs: true}
+		if err := atomicWrite(outFile.Name(), nil, attrs); err != nil {
 			t.Fatal(err)
 		}
 
@@ -47,7 +50,8 @@ func TestAtomicWrite(t *testing.T) {
 		}
 		os.Chmod(outFile.Name(), 0600)
 
-		if err := atomicWrite(outFile.Name(), nil, 0, true); err != nil {
+		attrs := fileAttrs{createDestDirs: true}
+		if err := atomicWrite(outFile.Name(), nil, attrs); err != nil {
 			t.Fatal(err)
 		}
 
@@ -72,7 +76,8 @@ func TestAtomicWrite(t *testing.T) {
 
 		// Try atomicWrite to a file that doesn't exist yet
 		file := filepath.Join(outDir, "nope/not/it/create")
-		if err := atomicWrite(file, nil, 0644, true); err != nil {
+		attrs := fileAttrs{perms: 0644, createDestDirs: true}
+		if err := atomicWrite(file, nil, attrs); err != nil {
 			t.Fatal(err)
 		}
 
@@ -91,12 +96,90 @@ func TestAtomicWrite(t *testing.T) {
 
 		// Try atomicWrite to a file that doesn't exist yet
 		file := filepath.Join(outDir, "nope/not/it/nope-no-create")
-		if err := atomicWrite(file, nil, 0644, false); err != errNoParentDir {
+		attrs := fileAttrs{perms: 0644}
+		if err := atomicWrite(file, nil, attrs); err != errNoParentDir {
 			t.Fatalf("expected %q to be %q", err, errNoParentDir)
 		}
 	})
 }
 
+func TestAtomicWriteAttrs(t *testing.T) {
+	t.Run("dir_perms", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+
+		file := filepath.Join(outDir, "sub/dir/file")
+		attrs := fileAttrs{createDestDirs: true, dirPerms: 0700}
+		if err := atomicWrite(file, nil, attrs); err != nil {
+			t.Fatal(err)
+		}
+
+		stat, err := os.Stat(filepath.Join(outDir, "sub"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode().Perm() != 0700 {
+			t.Errorf("expected %q to be %q", stat.Mode().Perm(), os.FileMode(0700))
+		}
+	})
+
+	t.Run("owner_numeric", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+
+		// chown to ourselves is always permitted
+		file := filepath.Join(outDir, "file")
+		attrs := fileAttrs{
+			user:  strconv.Itoa(os.Getuid()),
+			group: strconv.Itoa(os.Getgid()),
+		}
+		if err := atomicWrite(file, nil, attrs); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("owner_name", func(t *testing.T) {
+		u, err := user.Current()
+		if err != nil {
+			t.Skip("cannot lookup current user:", err)
+		}
+		uid, gid, err := lookupOwner(u.Username, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strconv.Itoa(uid) != u.Uid || gid != -1 {
+			t.Errorf("bad ids: %d, %d", uid, gid)
+		}
+	})
+
+	t.Run("owner_unknown", func(t *testing.T) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outDir)
+
+		file := filepath.Join(outDir, "file")
+		attrs := fileAttrs{user: "no-such-user-hcat"}
+		if err := atomicWrite(file, nil, attrs); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Fatal("file should not have been written")
+		}
+	})
+
+}
+
 func TestBackup(t *testing.T) {
 	t.Run("backup", func(t *testing.T) {
 		outDir, err := ioutil.TempDir("", "")
@@ -185,7 +268,8 @@ func TestBackup(t *testing.T) {
 		}
 
 		Backup(outFile.Name())
-		err = atomicWrite(outFile.Name(), []byte("second"), 0644, true)
+		attrs := fileAttrs{perms: 0644, createDestDirs: true}
+		err = atomicWrite(outFile.Name(), []byte("second"), attrs)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -216,7 +300,10 @@ func TestRender(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		fr := NewFileRenderer(FileRendererInput{Path: path})
+		fr, err := NewFileRenderer(FileRendererInput{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
 		rr, err := fr.Render(contents)
 		if err != nil {
 			t.Fatal(err)
@@ -248,7 +335,10 @@ func TestRender(t *testing.T) {
 		}
 
 		diff_contents := []byte("not-first")
-		fr := NewFileRenderer(FileRendererInput{Path: path})
+		fr, err := NewFileRenderer(FileRendererInput{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
 		rr, err := fr.Render(diff_contents)
 		if err != nil {
 			t.Fatal(err)
@@ -269,7 +359,10 @@ func TestRender(t *testing.T) {
 		path := path.Join(outDir, "no-exists")
 		contents := []byte("first")
 
-		fr := NewFileRenderer(FileRendererInput{Path: path})
+		fr, err := NewFileRenderer(FileRendererInput{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
 		rr, err := fr.Render(contents)
 		if err != nil {
 			t.Fatal(err)
@@ -290,7 +383,10 @@ func TestRender(t *testing.T) {
 		path := path.Join(outDir, "no-exists")
 		contents := []byte{}
 
-		fr := NewFileRenderer(FileRendererInput{Path: path})
+		fr, err := NewFileRenderer(FileRendererInput{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
 		rr, err := fr.Render(contents)
 		if err != nil {
 			t.Fatal(err)
@@ -182,14 +182,14 @@ func TestResolverRun(t *testing.T) {
 // Helpers
 
 func fooTemplate(t *testing.T) *Template {
-	return NewTemplate(
+	return mustTemplate(t,
 		TemplateInput{
 			Contents: `{{key "foo"}}`,
 		})
 }
 
 func echoTemplate(t *testing.T, data string) *Template {
-	return NewTemplate(
+	return mustTemplate(t,
 		TemplateInput{
 			Contents:     `{{echo "` + data + `"}}`,
 			FuncMapMerge: template.FuncMap{"echo": echoFunc},
@@ -227,7 +227,7 @@ func wordListFunc(recall Recaller) interface{} {
 
 func echoListTemplate(t *testing.T, data ...string) *Template {
 	list := strings.Join(data, `" "`)
-	return NewTemplate(
+	return mustTemplate(t,
 		TemplateInput{
 			Contents: `{{range words "` + list + `"}}{{echo .}}{{end}}`,
 			FuncMapMerge: template.FuncMap{
@@ -236,6 +236,14 @@ func echoListTemplate(t *testing.T, data ...string) *Template {
 		})
 }
 
+func mustTemplate(t *testing.T, i TemplateInput) *Template {
+	tmpl, err := NewTemplate(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
 // watcher with no Looker
 func blindWatcher(t *testing.T) *Watcher {
 	return NewWatcher(WatcherInput{Cache: NewStore()})
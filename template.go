@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/hex"
+	htmltemplate "html/template"
+	"io"
+	"sync"
 	"text/template"
 
 	"github.com/hashicorp/hcat/dep"
@@ -31,13 +34,25 @@ type Template struct {
 	leftDelim  string
 	rightDelim string
 
+	// name is the explicit identifier given on creation. If empty the hexMD5
+	// of the contents is used.
+	name string
+
 	// hexMD5 stores the hex version of the MD5
 	hexMD5 string
 
+	// tmpl is the parsed template. It is parsed once on creation and cloned
+	// for each execution to bind the recaller dependent functions.
+	tmpl parsedTemplate
+
 	// errMissingKey causes the template processing to exit immediately if a map
 	// is indexed with a key that does not exist.
 	errMissingKey bool
 
+	// escapeHTML executes the template with html/template's contextual
+	// autoescaping.
+	escapeHTML bool
+
 	// FuncMapMerge a map of functions that add-to or override
 	// those used when executing the template. (text/template)
 	funcMapMerge template.FuncMap
@@ -49,6 +64,17 @@ type Template struct {
 
 	// Renderer is the default renderer used for this template
 	renderer Renderer
+
+	// data is the value passed to the template as dot (.) on execution.
+	dataLock sync.RWMutex
+	data     interface{}
+
+	// waker is the watcher last used to execute the template, it is woken up
+	// when the data is updated.
+	waker waker
+
+	// renders tracks the dependencies and hashes for the RenderResult
+	renders renderState
 }
 
 // Renderer defines the interface used to render (output) and template.
@@ -63,6 +89,11 @@ type Recaller func(dep.Dependency) (value interface{}, found bool)
 
 // TemplateInput is used as input when creating the template.
 type TemplateInput struct {
+	// Name is the identifier for the template. It is used as the template's
+	// ID for dependency tracking and buffer periods and labels its errors.
+	// Names should be unique. If not set the MD5 hash of the contents is used.
+	Name string
+
 	// Contents are the raw template contents.
 	Contents string
 
@@ -74,6 +105,11 @@ type TemplateInput struct {
 	LeftDelim  string
 	RightDelim string
 
+	// EscapeHTML causes the template to be executed with html/template,
+	// contextually escaping the output for safe inclusion in HTML pages.
+	// All the template functions remain available.
+	EscapeHTML bool
+
 	// FuncMapMerge a map of functions that add-to or override those used when
 	// executing the template. (text/template)
 	//
@@ -92,22 +128,29 @@ type TemplateInput struct {
 
 	// Renderer is the default renderer used for this template
 	Renderer Renderer
+
+	// Data is the value made available as dot (.) in the template. It can be
+	// any value, usually a struct or a map[string]interface{} of variables
+	// (eg. instance name, region, feature flags). Use SetData to update it.
+	Data interface{}
 }
 
-// NewTemplate creates and parses a new Consul Template template at the given
-// path. If the template does not exist, an error is returned. During
-// initialization, the template is read and is parsed for dependencies. Any
-// errors that occur are returned.
-func NewTemplate(i TemplateInput) *Template {
+// NewTemplate creates and parses a new Consul Template template from the
+// given contents. The template is parsed once during initialization and any
+// parse errors that occur are returned.
+func NewTemplate(i TemplateInput) (*Template, error) {
 
 	var t Template
+	t.name = i.Name
 	t.contents = i.Contents
 	t.leftDelim = i.LeftDelim
 	t.rightDelim = i.RightDelim
 	t.errMissingKey = i.ErrMissingKey
+	t.escapeHTML = i.EscapeHTML
 	t.sandboxPath = i.SandboxPath
 	t.funcMapMerge = i.FuncMapMerge
 	t.renderer = i.Renderer
+	t.data = i.Data
 	t.dirty = make(drainableChan, 1)
 	t.Notify(nil) // prime template as needing to be run
 
@@ -115,11 +158,90 @@ func NewTemplate(i TemplateInput) *Template {
 	hash := md5.Sum([]byte(t.contents))
 	t.hexMD5 = hex.EncodeToString(hash[:])
 
-	return &t
+	tmpl, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+
+	return &t, nil
+}
+
+// parse builds the parse tree for the template contents. The dependency
+// functions are registered with a nil recaller, only their names matter for
+// parsing and they are re-bound for each execution.
+func (t *Template) parse() (parsedTemplate, error) {
+	funcs := funcMap(&funcMapInput{
+		funcMapMerge: t.funcMapMerge,
+	})
+	missingKey := "missingkey=zero"
+	if t.errMissingKey {
+		missingKey = "missingkey=error"
+	}
+
+	if t.escapeHTML {
+		tmpl, err := htmltemplate.New(t.ID()).
+			Delims(t.leftDelim, t.rightDelim).
+			Funcs(htmltemplate.FuncMap(funcs)).
+			Option(missingKey).
+			Parse(t.contents)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse")
+		}
+		return htmlTemplate{tmpl}, nil
+	}
+
+	tmpl, err := template.New(t.ID()).
+		Delims(t.leftDelim, t.rightDelim).
+		Funcs(funcs).
+		Option(missingKey).
+		Parse(t.contents)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse")
+	}
+	return textTemplate{tmpl}, nil
+}
+
+// parsedTemplate abstracts over the text/template and html/template parse
+// trees. Bind returns a copy of the template with the given functions
+// (re)bound, leaving the original untouched.
+type parsedTemplate interface {
+	bind(template.FuncMap) (executor, error)
 }
 
-// ID returns the identifier for this template.
+// executor is the execution half of text/template and html/template.
+type executor interface {
+	Execute(io.Writer, interface{}) error
+}
+
+type textTemplate struct{ *template.Template }
+
+func (t textTemplate) bind(funcs template.FuncMap) (executor, error) {
+	tmpl, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(funcs), nil
+}
+
+// htmlTemplate's original is never executed, only its clones, as html/template
+// refuses to clone a template after execution.
+type htmlTemplate struct{ *htmltemplate.Template }
+
+func (t htmlTemplate) bind(funcs template.FuncMap) (executor, error) {
+	tmpl, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(htmltemplate.FuncMap(funcs)), nil
+}
+
+// ID returns the identifier for this template. It is the name given on
+// creation or, if no name was given, the MD5 hash of the contents.
 func (t *Template) ID() string {
+	if t.name != "" {
+		return t.name
+	}
 	return t.hexMD5
 }
 
@@ -131,6 +253,34 @@ func (t *Template) Notify(dep.Dependency) {
 	}
 }
 
+// waker is implemented by the Watcher. Templates use it to wake up a watcher
+// blocked in Wait when they need re-rendering outside of a dependency update.
+type waker interface {
+	Wake(tmplID string)
+}
+
+// SetData updates the value made available as dot (.) in the template and
+// marks the template as needing to be re-rendered, like a dependency update.
+// If the template was executed with a Watcher, a call to that Watcher's Wait
+// returns so the Resolver can be run to render the new data.
+func (t *Template) SetData(data interface{}) {
+	t.dataLock.Lock()
+	t.data = data
+	wk := t.waker
+	t.dataLock.Unlock()
+	t.Notify(nil)
+	if wk != nil {
+		wk.Wake(t.ID())
+	}
+}
+
+// Data returns the value made available as dot (.) in the template.
+func (t *Template) Data() interface{} {
+	t.dataLock.RLock()
+	defer t.dataLock.RUnlock()
+	return t.data
+}
+
 // Check and clear dirty flag
 func (t *Template) isDirty() bool {
 	select {
@@ -141,38 +291,38 @@ func (t *Template) isDirty() bool {
 	}
 }
 
-// Render calls the stored Renderer with the passed content
+// Render calls the stored Renderer with the passed content. The result
+// includes the content's hash and the dependencies of the execution that
+// produced it.
 func (t *Template) Render(content []byte) (RenderResult, error) {
-	return t.renderer.Render(content)
+	return t.renders.render(t.renderer, content)
 }
 
 // Execute evaluates this template in the provided context.
 func (t *Template) Execute(w Watcherer) ([]byte, error) {
+	if wk, ok := w.(waker); ok {
+		t.dataLock.Lock()
+		t.waker = wk
+		t.dataLock.Unlock()
+	}
 	if !t.isDirty() {
 		return nil, ErrNoNewValues
 	}
 
-	tmpl := template.New(t.ID())
-	tmpl.Delims(t.leftDelim, t.rightDelim)
-	tmpl.Funcs(funcMap(&funcMapInput{
-		recaller:     w.Recaller(t),
+	// Clone the parsed template so the functions bound to this execution's
+	// recaller don't leak into (or race with) other executions.
+	deps := newDepRecorder(w.Recaller(t))
+	tmpl, err := t.tmpl.bind(funcMap(&funcMapInput{
+		recaller:     deps.recall,
 		funcMapMerge: t.funcMapMerge,
 	}))
-
-	if t.errMissingKey {
-		tmpl.Option("missingkey=error")
-	} else {
-		tmpl.Option("missingkey=zero")
-	}
-
-	tmpl, err := tmpl.Parse(t.contents)
 	if err != nil {
-		return nil, errors.Wrap(err, "parse")
+		return nil, errors.Wrap(err, "clone")
 	}
 
 	// Execute the template into the writer
 	var b bytes.Buffer
-	if err := tmpl.Execute(&b, nil); err != nil {
+	if err := tmpl.Execute(&b, t.Data()); err != nil {
 		return nil, errors.Wrap(err, "execute")
 	}
 
@@ -181,6 +331,7 @@ func (t *Template) Execute(w Watcherer) ([]byte, error) {
 	if !w.Complete(t) {
 		return nil, ErrMissingValues
 	}
+	t.renders.setDeps(deps.list())
 
 	return b.Bytes(), nil
 }
@@ -195,23 +346,36 @@ type funcMapInput struct {
 func funcMap(i *funcMapInput) template.FuncMap {
 
 	r := template.FuncMap{
-		"datacenters":  datacentersFunc(i.recaller),
-		"key":          keyFunc(i.recaller),
-		"keyExists":    keyExistsFunc(i.recaller),
-		"keyOrDefault": keyWithDefaultFunc(i.recaller),
-		"ls":           lsFunc(i.recaller, true),
-		"safeLs":       safeLsFunc(i.recaller),
-		"node":         nodeFunc(i.recaller),
-		"nodes":        nodesFunc(i.recaller),
-		"secret":       secretFunc(i.recaller),
-		"secrets":      secretsFunc(i.recaller),
-		"service":      serviceFunc(i.recaller),
-		"connect":      connectFunc(i.recaller),
-		"services":     servicesFunc(i.recaller),
-		"tree":         treeFunc(i.recaller, true),
-		"safeTree":     safeTreeFunc(i.recaller),
-		"caRoots":      connectCARootsFunc(i.recaller),
-		"caLeaf":       connectLeafFunc(i.recaller),
+		"datacenters":     datacentersFunc(i.recaller),
+		"key":             keyFunc(i.recaller),
+		"keyExists":       keyExistsFunc(i.recaller),
+		"keyOrDefault":    keyWithDefaultFunc(i.recaller),
+		"ls":              lsFunc(i.recaller, true),
+		"safeLs":          safeLsFunc(i.recaller),
+		"node":            nodeFunc(i.recaller),
+		"nodes":           nodesFunc(i.recaller),
+		"secret":          secretFunc(i.recaller),
+		"secrets":         secretsFunc(i.recaller),
+		"service":         serviceFunc(i.recaller),
+		"connect":         connectFunc(i.recaller),
+		"services":        servicesFunc(i.recaller),
+		"checks":          checksFunc(i.recaller),
+		"nodeChecks":      nodeChecksFunc(i.recaller),
+		"preparedQuery":   preparedQueryFunc(i.recaller),
+		"configEntry":     configEntryFunc(i.recaller),
+		"configEntries":   configEntriesFunc(i.recaller),
+		"intentions":      intentionsFunc(i.recaller),
+		"sessions":        sessionsFunc(i.recaller),
+		"lockHolder":      lockHolderFunc(i.recaller),
+		"aclToken":        aclTokenFunc(i.recaller),
+		"agentServices":   agentServicesFunc(i.recaller),
+		"agentChecks":     agentChecksFunc(i.recaller),
+		"tree":            treeFunc(i.recaller, true),
+		"safeTree":        safeTreeFunc(i.recaller),
+		"caRoots":         connectCARootsFunc(i.recaller),
+		"caLeaf":          connectLeafFunc(i.recaller),
+		"gatewayServices": gatewayServicesFunc(i.recaller),
+		"section":         SectionFunc,
 	}
 
 	for k, v := range i.funcMapMerge {
@@ -50,7 +50,9 @@ func datacentersFunc(recall Recaller) func(ignore ...bool) ([]string, error) {
 	}
 }
 
-// keyFunc returns or accumulates key dependencies.
+// keyFunc returns or accumulates key dependencies. The key can be followed by
+// a datacenter and namespace or partition, "key@dc?ns=name&partition=name", so
+// keys ending in such a parameter can't be read.
 func keyFunc(recall Recaller) func(string) (string, error) {
 	return func(s string) (string, error) {
 		if len(s) == 0 {
@@ -291,6 +293,216 @@ func serviceFunc(recall Recaller) func(...string) ([]*dep.HealthService, error)
 	}
 }
 
+// checksFunc returns or accumulates health checks by state dependencies.
+// The arguments are joined with "|" as for nodeChecks, but checks by state
+// take no filter, so any argument after the state is an error.
+func checksFunc(recall Recaller) func(...string) ([]*dep.HealthCheck, error) {
+	return func(s ...string) ([]*dep.HealthCheck, error) {
+		result := []*dep.HealthCheck{}
+
+		d, err := idep.NewHealthStateQuery(strings.Join(s, "|"))
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.HealthCheck), nil
+		}
+
+		return result, nil
+	}
+}
+
+// nodeChecksFunc returns or accumulates health checks by node dependencies.
+// The arguments are joined with "|", so those after the node name filter the
+// checks by status, e.g. nodeChecks "node1" "passing".
+func nodeChecksFunc(recall Recaller) func(...string) ([]*dep.HealthCheck, error) {
+	return func(s ...string) ([]*dep.HealthCheck, error) {
+		result := []*dep.HealthCheck{}
+
+		d, err := idep.NewHealthNodeQuery(strings.Join(s, "|"))
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.HealthCheck), nil
+		}
+
+		return result, nil
+	}
+}
+
+// preparedQueryFunc returns or accumulates prepared query dependencies. An
+// optional second argument sets the polling interval, e.g. "30s".
+func preparedQueryFunc(recall Recaller) func(string, ...string) (*dep.PreparedQueryResult, error) {
+	return func(s string, i ...string) (*dep.PreparedQueryResult, error) {
+		var interval time.Duration
+		switch len(i) {
+		case 0:
+		case 1:
+			var err error
+			interval, err = time.ParseDuration(i[0])
+			if err != nil {
+				return nil, errors.Wrap(err, "preparedQuery")
+			}
+		default:
+			return nil, fmt.Errorf("preparedQuery: wrong number of arguments, "+
+				"expected 1 or 2, but got %d", len(i)+1)
+		}
+
+		d, err := idep.NewPreparedQuery(s, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.(*dep.PreparedQueryResult), nil
+		}
+
+		return nil, nil
+	}
+}
+
+// configEntryFunc returns or accumulates config entry dependencies.
+func configEntryFunc(recall Recaller) func(string, string) (*dep.ConfigEntry, error) {
+	return func(kind, name string) (*dep.ConfigEntry, error) {
+		d, err := idep.NewConfigEntryQuery(kind + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok && value != nil {
+			return value.(*dep.ConfigEntry), nil
+		}
+
+		return nil, nil
+	}
+}
+
+// configEntriesFunc returns or accumulates config entries dependencies.
+func configEntriesFunc(recall Recaller) func(string) ([]*dep.ConfigEntry, error) {
+	return func(s string) ([]*dep.ConfigEntry, error) {
+		result := []*dep.ConfigEntry{}
+
+		d, err := idep.NewConfigEntriesQuery(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.ConfigEntry), nil
+		}
+
+		return result, nil
+	}
+}
+
+// intentionsFunc returns or accumulates Connect intentions dependencies.
+func intentionsFunc(recall Recaller) func(...string) ([]*dep.Intention, error) {
+	return func(s ...string) ([]*dep.Intention, error) {
+		result := []*dep.Intention{}
+
+		d, err := idep.NewConnectIntentionsQuery(strings.Join(s, ""))
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.Intention), nil
+		}
+
+		return result, nil
+	}
+}
+
+// sessionsFunc returns or accumulates session list dependencies.
+func sessionsFunc(recall Recaller) func(...string) ([]*dep.Session, error) {
+	return func(s ...string) ([]*dep.Session, error) {
+		result := []*dep.Session{}
+
+		d, err := idep.NewSessionsQuery(strings.Join(s, ""))
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.Session), nil
+		}
+
+		return result, nil
+	}
+}
+
+// lockHolderFunc returns or accumulates KV lock holder dependencies.
+func lockHolderFunc(recall Recaller) func(string) (*dep.Session, error) {
+	return func(s string) (*dep.Session, error) {
+		d, err := idep.NewKVLockQuery(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok && value != nil {
+			return value.(*dep.Session), nil
+		}
+
+		return nil, nil
+	}
+}
+
+// aclTokenFunc returns or accumulates the ACL token self dependency.
+func aclTokenFunc(recall Recaller) func() (*dep.ACLToken, error) {
+	return func() (*dep.ACLToken, error) {
+		d, err := idep.NewACLTokenSelfQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.(*dep.ACLToken), nil
+		}
+
+		return nil, nil
+	}
+}
+
+// agentServicesFunc returns or accumulates the local agent services
+// dependency.
+func agentServicesFunc(recall Recaller) func() ([]*dep.AgentService, error) {
+	return func() ([]*dep.AgentService, error) {
+		result := []*dep.AgentService{}
+
+		d, err := idep.NewAgentServicesQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.AgentService), nil
+		}
+
+		return result, nil
+	}
+}
+
+// agentChecksFunc returns or accumulates the local agent checks dependency.
+func agentChecksFunc(recall Recaller) func() ([]*dep.HealthCheck, error) {
+	return func() ([]*dep.HealthCheck, error) {
+		result := []*dep.HealthCheck{}
+
+		d, err := idep.NewAgentChecksQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.HealthCheck), nil
+		}
+
+		return result, nil
+	}
+}
+
 // servicesFunc returns or accumulates catalog services dependencies.
 func servicesFunc(recall Recaller) func(...string) ([]*dep.CatalogSnippet, error) {
 	return func(s ...string) ([]*dep.CatalogSnippet, error) {
@@ -355,6 +567,25 @@ func connectLeafFunc(recall Recaller) func(...string) (*api.LeafCert, error) {
 	}
 }
 
+// gatewayServicesFunc returns or accumulates the services linked to an
+// ingress or terminating gateway.
+func gatewayServicesFunc(recall Recaller) func(string) ([]*dep.GatewayService, error) {
+	return func(s string) ([]*dep.GatewayService, error) {
+		result := []*dep.GatewayService{}
+
+		d, err := idep.NewCatalogGatewayServicesQuery(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*dep.GatewayService), nil
+		}
+
+		return result, nil
+	}
+}
+
 func safeTreeFunc(recall Recaller) func(string) ([]*dep.KeyPair, error) {
 	// call treeFunc but explicitly mark that empty data set returned on
 	// monitored KV prefix is NOT safe
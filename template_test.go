@@ -2,11 +2,14 @@ package hcat
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -25,7 +28,9 @@ func TestNewTemplate(t *testing.T) {
 		{
 			"nil",
 			TemplateInput{},
-			NewTemplate(TemplateInput{}),
+			&Template{
+				hexMD5: "d41d8cd98f00b204e9800998ecf8427e",
+			},
 		},
 		{
 			"contents",
@@ -51,6 +56,30 @@ func TestNewTemplate(t *testing.T) {
 				rightDelim: ">>",
 			},
 		},
+		{
+			"name",
+			TemplateInput{
+				Name:     "foo",
+				Contents: "test",
+			},
+			&Template{
+				name:     "foo",
+				contents: "test",
+				hexMD5:   "098f6bcd4621d373cade4e832627b4f6",
+			},
+		},
+		{
+			"data",
+			TemplateInput{
+				Contents: "test",
+				Data:     map[string]interface{}{"foo": "bar"},
+			},
+			&Template{
+				contents: "test",
+				hexMD5:   "098f6bcd4621d373cade4e832627b4f6",
+				data:     map[string]interface{}{"foo": "bar"},
+			},
+		},
 		{
 			"err_missing_key",
 			TemplateInput{
@@ -67,8 +96,12 @@ func TestNewTemplate(t *testing.T) {
 
 	for i, tc := range cases {
 		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
-			tmpl := NewTemplate(tc.i)
+			tmpl, err := NewTemplate(tc.i)
+			if err != nil {
+				t.Fatal(err)
+			}
 			tc.e.dirty, tmpl.dirty = nil, nil // don't compare well
+			tmpl.tmpl = nil                   // parse tree tested via Execute
 			if !reflect.DeepEqual(tc.e, tmpl) {
 				t.Errorf("\nexp: %#v\nact: %#v", tc.e, tmpl)
 			}
@@ -76,6 +109,40 @@ func TestNewTemplate(t *testing.T) {
 	}
 }
 
+func TestTemplate_ID(t *testing.T) {
+	t.Run("hash", func(t *testing.T) {
+		a := mustTemplate(t, TemplateInput{Contents: "test"})
+		b := mustTemplate(t, TemplateInput{Contents: "test"})
+		if a.ID() != "098f6bcd4621d373cade4e832627b4f6" {
+			t.Errorf("bad id: %q", a.ID())
+		}
+		if a.ID() != b.ID() {
+			t.Errorf("expected same ids, got %q and %q", a.ID(), b.ID())
+		}
+	})
+	t.Run("name", func(t *testing.T) {
+		a := mustTemplate(t, TemplateInput{Name: "a", Contents: "test"})
+		b := mustTemplate(t, TemplateInput{Name: "b", Contents: "test"})
+		if a.ID() != "a" || b.ID() != "b" {
+			t.Errorf("bad ids: %q, %q", a.ID(), b.ID())
+		}
+	})
+	t.Run("error-label", func(t *testing.T) {
+		_, err := NewTemplate(TemplateInput{
+			Name: "my-template", Contents: "{{ bad_func }}"})
+		if err == nil || !strings.Contains(err.Error(), "my-template") {
+			t.Errorf("expected parse error labeled with name, got %v", err)
+		}
+		tpl := mustTemplate(t, TemplateInput{
+			Name: "my-template", Contents: "{{ .foo }}", ErrMissingKey: true,
+			Data: map[string]interface{}{}})
+		_, err = tpl.Execute(fakeWatcher{NewStore()})
+		if err == nil || !strings.Contains(err.Error(), "my-template") {
+			t.Errorf("expected execute error labeled with name, got %v", err)
+		}
+	})
+}
+
 func TestTemplate_Execute(t *testing.T) {
 	t.Parallel()
 	now = func() time.Time { return time.Unix(0, 0).UTC() }
@@ -149,6 +216,41 @@ func TestTemplate_Execute(t *testing.T) {
 			"<no value>",
 			false,
 		},
+
+		// template data
+		{
+			"data_struct",
+			TemplateInput{
+				Contents: `{{ .Name }} in {{ .Region }}`,
+				Data: struct{ Name, Region string }{
+					Name: "web-1", Region: "us-east-1"},
+			},
+			nil,
+			"web-1 in us-east-1",
+			false,
+		},
+		{
+			"data_vars",
+			TemplateInput{
+				Contents: `{{ if .feature }}{{ .name }}{{ end }}`,
+				Data: map[string]interface{}{
+					"name": "web-1", "feature": true},
+			},
+			nil,
+			"web-1",
+			false,
+		},
+		{
+			"data_vars_err_missing_keys",
+			TemplateInput{
+				Contents:      `{{ .missing }}`,
+				Data:          map[string]interface{}{"name": "web-1"},
+				ErrMissingKey: true,
+			},
+			nil,
+			"",
+			true,
+		},
 		{
 			"func_datacenters",
 			TemplateInput{
@@ -200,6 +302,29 @@ func TestTemplate_Execute(t *testing.T) {
 			"5",
 			false,
 		},
+		{
+			"func_key_namespaces",
+			TemplateInput{
+				Contents: `{{ key "key?ns=team-a" }} {{ key "key@dc1?ns=team-b" }} {{ key "key?partition=eu" }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				for k, v := range map[string]string{
+					"key?ns=team-a":     "a",
+					"key@dc1?ns=team-b": "b",
+					"key?partition=eu":  "c",
+				} {
+					d, err := idep.NewKVGetQuery(k)
+					if err != nil {
+						t.Fatal(err)
+					}
+					st.Save(d.String(), v)
+				}
+				return st
+			}(),
+			"a b c",
+			false,
+		},
 		{
 			"func_keyExists",
 			TemplateInput{
@@ -299,6 +424,280 @@ func TestTemplate_Execute(t *testing.T) {
 			"node1node2",
 			false,
 		},
+		{
+			"func_checks",
+			TemplateInput{
+				Contents: `{{ range checks "critical" }}{{ .Node }}:{{ .CheckID }} {{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewHealthStateQuery("critical")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.HealthCheck{
+					{Node: "node1", CheckID: "serfHealth", Status: "critical"},
+					{Node: "node2", CheckID: "service:web", Status: "critical"},
+				})
+				return st
+			}(),
+			"node1:serfHealth node2:service:web ",
+			false,
+		},
+		{
+			"func_checks_extra_arg",
+			TemplateInput{
+				Contents: `{{ checks "critical" "passing" }}`,
+			},
+			nil,
+			"",
+			true,
+		},
+		{
+			"func_node_checks",
+			TemplateInput{
+				Contents: `{{ range nodeChecks "node1" "passing" }}{{ .Name }}{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewHealthNodeQuery("node1|passing")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.HealthCheck{
+					{Node: "node1", Name: "Serf Health Status"},
+				})
+				return st
+			}(),
+			"Serf Health Status",
+			false,
+		},
+		{
+			"func_prepared_query",
+			TemplateInput{
+				Contents: `{{ with preparedQuery "web-failover@dc1" "30s" }}{{ .Datacenter }}:{{ .Failovers }}{{ range .Nodes }} {{ .Address }}{{ end }}{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewPreparedQuery("web-failover@dc1", 30*time.Second)
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), &dep.PreparedQueryResult{
+					Service:    "web",
+					Datacenter: "dc2",
+					Failovers:  1,
+					Nodes: []*dep.HealthService{
+						{Address: "1.2.3.4"},
+						{Address: "5.6.7.8"},
+					},
+				})
+				return st
+			}(),
+			"dc2:1 1.2.3.4 5.6.7.8",
+			false,
+		},
+		{
+			"func_prepared_query_bad_interval",
+			TemplateInput{
+				Contents: `{{ preparedQuery "web" "soon" }}`,
+			},
+			NewStore(),
+			"",
+			true,
+		},
+		{
+			"func_config_entry",
+			TemplateInput{
+				Contents: `{{ with configEntry "service-resolver" "web@dc1" }}{{ .Name }} {{ .Config.ConnectTimeout }}{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewConfigEntryQuery("service-resolver/web@dc1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), &dep.ConfigEntry{
+					Kind: "service-resolver",
+					Name: "web",
+					Config: map[string]interface{}{
+						"Kind":           "service-resolver",
+						"Name":           "web",
+						"ConnectTimeout": "5s",
+					},
+				})
+				return st
+			}(),
+			"web 5s",
+			false,
+		},
+		{
+			"func_config_entry_missing",
+			TemplateInput{
+				Contents: `{{ with configEntry "service-resolver" "web" }}found{{ else }}missing{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewConfigEntryQuery("service-resolver/web")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), nil)
+				return st
+			}(),
+			"missing",
+			false,
+		},
+		{
+			"func_config_entries",
+			TemplateInput{
+				Contents: `{{ range configEntries "ingress-gateway" }}{{ .Name }} {{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewConfigEntriesQuery("ingress-gateway")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.ConfigEntry{
+					{Kind: "ingress-gateway", Name: "edge"},
+					{Kind: "ingress-gateway", Name: "internal"},
+				})
+				return st
+			}(),
+			"edge internal ",
+			false,
+		},
+		{
+			"func_intentions",
+			TemplateInput{
+				Contents: `{{ range intentions "destination=db" }}{{ .SourceName }}:{{ .Action }} {{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewConnectIntentionsQuery("destination=db")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.Intention{
+					{SourceName: "web", DestinationName: "db", Action: "allow"},
+					{SourceName: "*", DestinationName: "db", Action: "deny"},
+				})
+				return st
+			}(),
+			"web:allow *:deny ",
+			false,
+		},
+		{
+			"func_sessions",
+			TemplateInput{
+				Contents: `{{ range sessions "node1@dc1" }}{{ .ID }}:{{ .Behavior }} {{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewSessionsQuery("node1@dc1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.Session{
+					{ID: "abc", Node: "node1", Behavior: "release"},
+					{ID: "def", Node: "node1", Behavior: "delete"},
+				})
+				return st
+			}(),
+			"abc:release def:delete ",
+			false,
+		},
+		{
+			"func_lock_holder",
+			TemplateInput{
+				Contents: `{{ with lockHolder "service/web/leader" }}{{ .Node }}{{ else }}none{{ end }} {{ with lockHolder "service/db/leader" }}{{ .Node }}{{ else }}none{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewKVLockQuery("service/web/leader")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), &dep.Session{ID: "abc", Node: "node1"})
+				d, err = idep.NewKVLockQuery("service/db/leader")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), nil)
+				return st
+			}(),
+			"node1 none",
+			false,
+		},
+		{
+			"func_gateway_services",
+			TemplateInput{
+				Contents: `{{ range gatewayServices "ingress" }}{{ .Service }}:{{ .Port }}/{{ .Protocol }}{{ range .Hosts }} {{ . }}{{ end }};{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewCatalogGatewayServicesQuery("ingress")
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), []*dep.GatewayService{
+					{Gateway: "ingress", Service: "web", Port: 8080,
+						Protocol: "http", Hosts: []string{"web.example.com"}},
+					{Gateway: "ingress", Service: "db", Port: 9090,
+						Protocol: "tcp"},
+				})
+				return st
+			}(),
+			"web:8080/http web.example.com;db:9090/tcp;",
+			false,
+		},
+		{
+			"func_acl_token",
+			TemplateInput{
+				Contents: `{{ with aclToken }}{{ .AccessorID }} {{ .HasPolicy "operator" }} {{ .HasRole "admin" }}{{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				d, err := idep.NewACLTokenSelfQuery()
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(d.String(), &dep.ACLToken{
+					AccessorID: "abcd",
+					Policies:   []dep.ACLLink{{ID: "1", Name: "operator"}},
+				})
+				return st
+			}(),
+			"abcd true false",
+			false,
+		},
+		{
+			"func_agent_services_checks",
+			TemplateInput{
+				Contents: `{{ range agentServices }}{{ .ID }}:{{ .Port }} {{ end }}{{ range agentChecks }}{{ .CheckID }}={{ .Status }} {{ end }}`,
+			},
+			func() *Store {
+				st := NewStore()
+				s, err := idep.NewAgentServicesQuery()
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(s.String(), []*dep.AgentService{
+					{ID: "web-1", Service: "web", Port: 8080},
+				})
+				c, err := idep.NewAgentChecksQuery()
+				if err != nil {
+					t.Fatal(err)
+				}
+				st.Save(c.String(), []*dep.HealthCheck{
+					{CheckID: "service:web-1", Status: "passing"},
+				})
+				return st
+			}(),
+			"web-1:8080 service:web-1=passing ",
+			false,
+		},
 		{
 			"func_secret_read",
 			TemplateInput{
@@ -697,7 +1096,13 @@ func TestTemplate_Execute(t *testing.T) {
 
 	for i, tc := range cases {
 		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
-			tpl := NewTemplate(tc.ti)
+			tpl, err := NewTemplate(tc.ti)
+			if err != nil {
+				if !tc.err {
+					t.Fatal(err)
+				}
+				return
+			}
 
 			w := fakeWatcher{tc.i}
 			a, err := tpl.Execute(w)
@@ -711,6 +1116,156 @@ func TestTemplate_Execute(t *testing.T) {
 	}
 }
 
+func TestTemplate_SetData(t *testing.T) {
+	tpl, err := NewTemplate(TemplateInput{
+		Contents: `{{ .region }}`,
+		Data:     map[string]interface{}{"region": "us-east-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := fakeWatcher{NewStore()}
+
+	a, err := tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != "us-east-1" {
+		t.Errorf("bad output: %q", a)
+	}
+
+	if _, err := tpl.Execute(w); err != ErrNoNewValues {
+		t.Fatalf("expected %v, got %v", ErrNoNewValues, err)
+	}
+
+	tpl.SetData(map[string]interface{}{"region": "eu-west-1"})
+	a, err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != "eu-west-1" {
+		t.Errorf("bad output: %q", a)
+	}
+}
+
+func TestTemplate_SetDataWakesWatcher(t *testing.T) {
+	tpl, err := NewTemplate(TemplateInput{
+		Contents: `{{ .region }}`,
+		Data:     map[string]interface{}{"region": "us-east-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := NewResolver()
+	w := blindWatcher(t)
+	defer w.Stop()
+
+	r, err := rv.Run(tpl, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Complete || string(r.Contents) != "us-east-1" {
+		t.Fatalf("bad result: %#v", r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := w.WaitCh(ctx)
+	tpl.SetData(map[string]interface{}{"region": "eu-west-1"})
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait not woken by SetData")
+	}
+
+	r, err = rv.Run(tpl, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Complete || string(r.Contents) != "eu-west-1" {
+		t.Fatalf("bad result: %#v", r)
+	}
+}
+
+func TestTemplate_EscapeHTML(t *testing.T) {
+	st := NewStore()
+	d, err := idep.NewKVGetQuery("motd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Save(d.String(), `<script>alert("hi")</script>`)
+	w := fakeWatcher{st}
+
+	cases := []struct {
+		name string
+		ti   TemplateInput
+		e    string
+	}{
+		{
+			"text",
+			TemplateInput{
+				Contents: `<p>{{ key "motd" }}</p>`,
+			},
+			`<p><script>alert("hi")</script></p>`,
+		},
+		{
+			"html",
+			TemplateInput{
+				Contents:   `<p>{{ key "motd" }}</p>`,
+				EscapeHTML: true,
+			},
+			`<p>&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</p>`,
+		},
+		{
+			"html_attr",
+			TemplateInput{
+				Contents:   `<a title="{{ key "motd" }}">`,
+				EscapeHTML: true,
+			},
+			`<a title="&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;">`,
+		},
+		{
+			"html_func_merge",
+			TemplateInput{
+				Contents:     `<p>{{ key "motd" | upper }}</p>`,
+				EscapeHTML:   true,
+				FuncMapMerge: template.FuncMap{"upper": strings.ToUpper},
+			},
+			`<p>&lt;SCRIPT&gt;ALERT(&#34;HI&#34;)&lt;/SCRIPT&gt;</p>`,
+		},
+		{
+			"html_delims",
+			TemplateInput{
+				Contents:   `<p><< key "motd" >></p>`,
+				EscapeHTML: true,
+				LeftDelim:  "<<",
+				RightDelim: ">>",
+			},
+			`<p>&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</p>`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := mustTemplate(t, tc.ti)
+			// execute twice to verify the parsed template is reusable
+			for i := 0; i < 2; i++ {
+				tpl.Notify(nil)
+				a, err := tpl.Execute(w)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(a) != tc.e {
+					t.Errorf("\nexp: %#v\nact: %#v", tc.e, string(a))
+				}
+			}
+		})
+	}
+}
+
 type fakeWatcher struct {
 	*Store
 }
@@ -722,3 +1277,69 @@ func (f fakeWatcher) Recaller(Notifier) Recaller {
 		return f.Store.Recall(d.String())
 	}
 }
+
+// benchTemplateContents generates a template with n service blocks, each
+// using a dependency function, pipelines and conditionals.
+func benchTemplateContents(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "upstream svc%d {\n", i)
+		b.WriteString(`{{- range service "web" }}{{ if eq .Status "passing" }}` +
+			"\n  server {{ .Address }}:{{ .Port }}; # {{ .Node }}" +
+			`{{ else }}` + "\n  # down {{ .Node }}" + `{{ end }}{{ end }}` +
+			"\n  keepalive {{ keyOrDefault \"keepalive\" \"16\" }};\n}\n")
+	}
+	return b.String()
+}
+
+func benchTemplateStore(b *testing.B) *Store {
+	st := NewStore()
+	d, err := idep.NewHealthServiceQuery("web")
+	if err != nil {
+		b.Fatal(err)
+	}
+	st.Save(d.String(), []*dep.HealthService{
+		{Node: "node1", Address: "1.2.3.4", Port: 80, Status: "passing"},
+		{Node: "node2", Address: "5.6.7.8", Port: 80, Status: "critical"},
+	})
+	return st
+}
+
+// BenchmarkTemplate_Execute measures executing a template with its cached
+// parse tree against re-parsing it for every execution.
+func BenchmarkTemplate_Execute(b *testing.B) {
+	for _, size := range []int{1, 10, 100} {
+		tpl, err := NewTemplate(TemplateInput{
+			Contents: benchTemplateContents(size),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		w := fakeWatcher{benchTemplateStore(b)}
+
+		b.Run(fmt.Sprintf("cached_%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tpl.Notify(nil)
+				if _, err := tpl.Execute(w); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("reparse_%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tpl.Notify(nil)
+				tmpl, err := tpl.parse()
+				if err != nil {
+					b.Fatal(err)
+				}
+				tpl.tmpl = tmpl
+				if _, err := tpl.Execute(w); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
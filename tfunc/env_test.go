@@ -39,7 +39,13 @@ func TestEnvExecute(t *testing.T) {
 
 	for i, tc := range cases {
 		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
-			tpl := NewTemplate(tc.ti)
+			tpl, err := NewTemplate(tc.ti)
+			if err != nil {
+				if !tc.err {
+					t.Fatal(err)
+				}
+				return
+			}
 
 			a, err := tpl.Execute(tc.i)
 			if (err != nil) != tc.err {
@@ -27,6 +27,16 @@ func TestStringExecute(t *testing.T) {
 			"    hello\n    hello\r\n    HELLO\r\n    hello\n    HELLO",
 			false,
 		},
+		{
+			"escape_html",
+			hcat.TemplateInput{
+				Contents:   `<b>{{ "a<b" | toUpper }}</b>`,
+				EscapeHTML: true,
+			},
+			fakeWatcher{hcat.NewStore()},
+			"<b>A&lt;B</b>",
+			false,
+		},
 		{
 			"indent_negative",
 			hcat.TemplateInput{
@@ -103,7 +113,13 @@ func TestStringExecute(t *testing.T) {
 
 	for i, tc := range cases {
 		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
-			tpl := NewTemplate(tc.ti)
+			tpl, err := NewTemplate(tc.ti)
+			if err != nil {
+				if !tc.err {
+					t.Fatal(err)
+				}
+				return
+			}
 
 			a, err := tpl.Execute(tc.i)
 			if (err != nil) != tc.err {
@@ -31,7 +31,7 @@ func TestAllForDups(t *testing.T) {
 }
 
 // Wrap the new template to use our template library
-func NewTemplate(ti hcat.TemplateInput) *hcat.Template {
+func NewTemplate(ti hcat.TemplateInput) (*hcat.Template, error) {
 	switch ti.FuncMapMerge {
 	case nil:
 		ti.FuncMapMerge = All()
@@ -0,0 +1,103 @@
+package hcat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// pathPlaceholder is replaced with the path of the file to validate in the
+// arguments of ValidateCommand.
+const pathPlaceholder = "{{path}}"
+
+// ValidateFunc defines the function type passed in to validate the rendered
+// contents before they are written. It is called with the path to a temporary
+// file holding the candidate contents and should return an error if they are
+// invalid.
+type ValidateFunc func(path string) error
+
+// ValidationError is returned by the FileRenderer when the rendered contents
+// fail validation. The existing file is left untouched.
+type ValidationError struct {
+	// Path is the destination path of the rejected contents
+	Path string
+	// Err is the error returned by the ValidateFunc
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed for %q: %s", e.Path, e.Err)
+}
+
+// Cause returns the underlying error (for github.com/pkg/errors).
+func (e *ValidationError) Cause() error { return e.Err }
+
+// Unwrap returns the underlying error (for errors.Is/As).
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// CommandError is the error returned by ValidateCommand when the command
+// fails. It includes the command's combined output.
+type CommandError struct {
+	Command string
+	Output  []byte
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	out := strings.TrimSpace(string(e.Output))
+	if out == "" {
+		return fmt.Sprintf("%q: %s", e.Command, e.Err)
+	}
+	return fmt.Sprintf("%q: %s: %s", e.Command, e.Err, out)
+}
+
+// Unwrap returns the underlying error (for errors.Is/As).
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// ValidateCommand returns a ValidateFunc that runs an external command to
+// validate the contents, eg. "nginx -t -c {{path}}". The command is split on
+// whitespace (it is not run in a shell) and {{path}} is replaced with the path
+// of the file to validate. A non-zero exit status rejects the contents.
+func ValidateCommand(command string) ValidateFunc {
+	return func(path string) error {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return fmt.Errorf("empty validate command")
+		}
+		for i, a := range args {
+			args[i] = strings.Replace(a, pathPlaceholder, path, -1)
+		}
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			return &CommandError{Command: command, Output: out, Err: err}
+		}
+		return nil
+	}
+}
+
+// validate writes the contents to a temporary file, with the same extension
+// as path, and passes it to the validate function. Errors from the validate
+// function are returned as a ValidationError.
+func validate(path string, contents []byte, fn ValidateFunc) error {
+	f, err := ioutil.TempFile("", "hcat-validate-*"+filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := fn(f.Name()); err != nil {
+		return &ValidationError{Path: path, Err: err}
+	}
+	return nil
+}
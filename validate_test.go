@@ -0,0 +1,165 @@
+package hcat
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRenderValidate(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		outDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(outDir, "out.conf")
+		if err := ioutil.WriteFile(path, []byte("good"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path, func() { os.RemoveAll(outDir) }
+	}
+	checkContents := func(t *testing.T, path, exp string) {
+		act, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(act) != exp {
+			t.Errorf("\nexp: %#v\nact: %#v", exp, string(act))
+		}
+	}
+	errBad := errors.New("bad contents")
+	validator := func(path string) error {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(contents), "bad") {
+			return errBad
+		}
+		return nil
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path: path, Validate: validator})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr, err := fr.Render([]byte("better"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		checkContents(t, path, "better")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		backedUp := false
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path:     path,
+			Validate: validator,
+			Backup:   func(string) { backedUp = true },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr, err := fr.Render([]byte("bad"))
+		var verr *ValidationError
+		switch {
+		case !errors.As(err, &verr):
+			t.Fatalf("expected a *ValidationError, got %v", err)
+		case verr.Path != path:
+			t.Errorf("bad error path: %q", verr.Path)
+		case !errors.Is(err, errBad):
+			t.Errorf("expected wrapped error %v, got %v", errBad, verr.Err)
+		}
+		if rr.DidRender || rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+		if backedUp {
+			t.Error("backup made of rejected contents")
+		}
+		checkContents(t, path, "good")
+	})
+	t.Run("temp-file", func(t *testing.T) {
+		path, cleanup := setup(t)
+		defer cleanup()
+
+		var tmp string
+		fr, err := NewFileRenderer(FileRendererInput{
+			Path: path,
+			Validate: func(p string) error {
+				tmp = p
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fr.Render([]byte("new")); err != nil {
+			t.Fatal(err)
+		}
+		if tmp == path || filepath.Ext(tmp) != ".conf" {
+			t.Errorf("bad temp file: %q", tmp)
+		}
+		if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+			t.Errorf("temp file not removed: %v", err)
+		}
+	})
+}
+
+func TestValidateCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses unix commands")
+	}
+	path, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path.Name())
+	if _, err := path.WriteString("listen 80;\n"); err != nil {
+		t.Fatal(err)
+	}
+	path.Close()
+
+	t.Run("success", func(t *testing.T) {
+		err := ValidateCommand("grep -q listen {{path}}")(path.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		err := ValidateCommand("grep server {{path}}")(path.Name())
+		var cerr *CommandError
+		if !errors.As(err, &cerr) {
+			t.Fatalf("expected a *CommandError, got %v", err)
+		}
+		if cerr.Command != "grep server {{path}}" {
+			t.Errorf("bad command: %q", cerr.Command)
+		}
+	})
+	t.Run("output", func(t *testing.T) {
+		err := ValidateCommand("ls {{path}}.missing")(path.Name())
+		if err == nil || !strings.Contains(err.Error(), ".missing") {
+			t.Fatalf("expected command output in error, got %v", err)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		if err := ValidateCommand(" ")(path.Name()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package hcat
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcat/dep"
+	idep "github.com/hashicorp/hcat/internal/dependency"
+	"github.com/pkg/errors"
+)
+
+// VaultKVRenderer renders the template text to a Vault KV (v1 or v2) secret.
+type VaultKVRenderer struct {
+	clients dep.Clients
+	path    string
+	key     string
+
+	mu sync.Mutex
+	// apiPath and isKVv2 are looked up on the first render
+	apiPath string
+	isKVv2  bool
+}
+
+// check for interface compliance
+var _ Renderer = (*VaultKVRenderer)(nil)
+
+// VaultKVRendererInput is the input structure for NewVaultKVRenderer.
+type VaultKVRendererInput struct {
+	// Clients provides the Vault client, eg. the ClientSet (Looker)
+	Clients dep.Clients
+	// Path is the path of the secret, as used with the secret function
+	// (eg. "secret/foo", without "data/" for KV v2)
+	Path string
+	// Key is the secret's field the contents are written to, other fields
+	// are kept. If empty the contents must be a JSON object, which replaces
+	// all the secret's data.
+	Key string
+}
+
+// NewVaultKVRenderer returns a new VaultKVRenderer.
+func NewVaultKVRenderer(i VaultKVRendererInput) *VaultKVRenderer {
+	return &VaultKVRenderer{
+		clients: i.Clients,
+		path:    strings.Trim(strings.TrimSpace(i.Path), "/"),
+		key:     i.Key,
+	}
+}
+
+// Render writes the contents to the secret if they differ from its current
+// data. On KV v2 the write uses check-and-set on the version read, returning
+// ErrWriteConflict if the secret was changed in between.
+func (r *VaultKVRenderer) Render(contents []byte) (RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.path == "" {
+		return RenderResult{}, errMissingDest
+	}
+	client := r.clients.Vault()
+
+	if r.apiPath == "" {
+		apiPath, isKVv2, err := idep.VaultKVPath(client, r.path)
+		if err != nil {
+			return RenderResult{}, errors.Wrap(err, "failed checking KV version")
+		}
+		r.apiPath, r.isKVv2 = apiPath, isKVv2
+	}
+
+	secret, err := client.Logical().Read(r.apiPath)
+	if err != nil {
+		return RenderResult{}, errors.Wrap(err, "failed reading secret")
+	}
+	var existing map[string]interface{}
+	var version json.Number = "0"
+	if secret != nil {
+		existing = secret.Data
+		if r.isKVv2 {
+			existing, _ = secret.Data["data"].(map[string]interface{})
+			if md, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+				if v, ok := md["version"].(json.Number); ok {
+					version = v
+				}
+			}
+		}
+	}
+
+	data, err := r.data(contents, existing)
+	if err != nil {
+		return RenderResult{}, err
+	}
+	if existing != nil && reflect.DeepEqual(existing, data) {
+		return RenderResult{
+			DidRender:   false,
+			WouldRender: true,
+		}, nil
+	}
+
+	body := data
+	if r.isKVv2 {
+		body = map[string]interface{}{
+			"data":    data,
+			"options": map[string]interface{}{"cas": version},
+		}
+	}
+	if _, err := client.Logical().Write(r.apiPath, body); err != nil {
+		if r.isKVv2 && strings.Contains(err.Error(), "check-and-set") {
+			return RenderResult{}, ErrWriteConflict
+		}
+		return RenderResult{}, errors.Wrap(err, "failed writing secret")
+	}
+
+	return RenderResult{
+		DidRender:   true,
+		WouldRender: true,
+	}, nil
+}
+
+// data returns the secret data to write for the contents.
+func (r *VaultKVRenderer) data(contents []byte,
+	existing map[string]interface{}) (map[string]interface{}, error) {
+	if r.key != "" {
+		data := make(map[string]interface{}, len(existing)+1)
+		for k, v := range existing {
+			data[k] = v
+		}
+		data[r.key] = string(contents)
+		return data, nil
+	}
+
+	// decoded like the Vault API's secrets for comparison
+	var data map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(contents))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil || data == nil {
+		return nil, errors.New("contents must be a JSON object when no key is set")
+	}
+	return data, nil
+}
@@ -0,0 +1,192 @@
+package hcat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVaultKV is a minimal fake of a Vault KV mount at "secret/".
+type fakeVaultKV struct {
+	sync.Mutex
+	v2       bool
+	data     map[string]map[string]interface{}
+	versions map[string]int
+	writes   int
+	// beforeWrite is called before a write is applied, to simulate
+	// concurrent updates
+	beforeWrite func()
+}
+
+func newFakeVaultKV(v2 bool) (*fakeVaultKV, *httptest.Server) {
+	kv := &fakeVaultKV{
+		v2:       v2,
+		data:     make(map[string]map[string]interface{}),
+		versions: make(map[string]int),
+	}
+	return kv, httptest.NewServer(kv)
+}
+
+func (f *fakeVaultKV) set(path string, data map[string]interface{}) {
+	f.data[path] = data
+	f.versions[path]++
+}
+
+func (f *fakeVaultKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	respond := func(code int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(v)
+	}
+	if strings.HasPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/") {
+		version := "1"
+		if f.v2 {
+			version = "2"
+		}
+		respond(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"path": "secret/", "type": "kv",
+			"options": map[string]interface{}{"version": version},
+		}})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	if f.v2 {
+		if !strings.HasPrefix(path, "data/") {
+			respond(http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		path = strings.TrimPrefix(path, "data/")
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.data[path]
+		if !ok {
+			respond(http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		if f.v2 {
+			respond(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": f.versions[path]},
+			}})
+			return
+		}
+		respond(http.StatusOK, map[string]interface{}{"data": data})
+	case http.MethodPut, http.MethodPost:
+		if f.beforeWrite != nil {
+			f.beforeWrite()
+			f.beforeWrite = nil
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respond(http.StatusBadRequest, map[string]interface{}{
+				"errors": []string{err.Error()}})
+			return
+		}
+		data := body
+		if f.v2 {
+			data, _ = body["data"].(map[string]interface{})
+			opts, _ := body["options"].(map[string]interface{})
+			if cas, ok := opts["cas"].(float64); ok && int(cas) != f.versions[path] {
+				respond(http.StatusBadRequest, map[string]interface{}{"errors": []string{
+					"check-and-set parameter did not match the current version"}})
+				return
+			}
+		}
+		f.writes++
+		f.set(path, data)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestVaultKVRenderer(t *testing.T) {
+	setup := func(t *testing.T, v2 bool, i VaultKVRendererInput) (
+		*fakeVaultKV, *VaultKVRenderer, func()) {
+		kv, srv := newFakeVaultKV(v2)
+		clients := NewClientSet()
+		err := clients.AddVault(VaultInput{Address: srv.URL, Token: "token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		i.Clients = clients
+		return kv, NewVaultKVRenderer(i), func() { clients.Stop(); srv.Close() }
+	}
+	render := func(t *testing.T, r *VaultKVRenderer, contents string, did bool) {
+		t.Helper()
+		rr, err := r.Render([]byte(contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr.DidRender != did || !rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+	}
+
+	for _, v2 := range []bool{false, true} {
+		name := "v1"
+		if v2 {
+			name = "v2"
+		}
+		t.Run(name+"-key", func(t *testing.T) {
+			kv, r, cleanup := setup(t, v2, VaultKVRendererInput{
+				Path: "secret/app", Key: "config"})
+			defer cleanup()
+			kv.set("app", map[string]interface{}{"other": "keep"})
+
+			render(t, r, "one", true)
+			render(t, r, "one", false)
+			render(t, r, "two", true)
+
+			data := kv.data["app"]
+			if data["config"] != "two" || data["other"] != "keep" {
+				t.Fatalf("bad secret data: %v", data)
+			}
+			if kv.writes != 2 {
+				t.Errorf("expected 2 writes, got %d", kv.writes)
+			}
+		})
+		t.Run(name+"-json", func(t *testing.T) {
+			kv, r, cleanup := setup(t, v2, VaultKVRendererInput{Path: "secret/app"})
+			defer cleanup()
+
+			render(t, r, `{"user": "admin", "port": 5432}`, true)
+			render(t, r, `{"port": 5432, "user": "admin"}`, false)
+
+			data := kv.data["app"]
+			if data["user"] != "admin" || data["port"] != float64(5432) {
+				t.Fatalf("bad secret data: %v", data)
+			}
+			if _, err := r.Render([]byte("not json")); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	t.Run("v2-conflict", func(t *testing.T) {
+		kv, r, cleanup := setup(t, true, VaultKVRendererInput{
+			Path: "secret/app", Key: "config"})
+		defer cleanup()
+		kv.set("app", map[string]interface{}{"config": "one"})
+		kv.beforeWrite = func() {
+			kv.set("app", map[string]interface{}{"config": "other"})
+		}
+
+		if _, err := r.Render([]byte("two")); err != ErrWriteConflict {
+			t.Fatalf("expected %v, got %v", ErrWriteConflict, err)
+		}
+		if v := kv.data["app"]["config"]; v != "other" {
+			t.Errorf("value clobbered: %v", v)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package hcat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -183,7 +184,9 @@ func (v *view) poll(viewCh chan<- *view, errCh chan<- error) {
 			retries = 0
 			goto WAIT
 		case err := <-fetchErrCh:
-			if v.retryFunc != nil {
+			// An expired token won't come back, so there is no point retrying.
+			var expired *dep.ACLTokenExpiredError
+			if v.retryFunc != nil && !errors.As(err, &expired) {
 				retry, sleep := v.retryFunc(retries)
 				if retry {
 					//log.Printf("[WARN] (view) %s (retry attempt %d after %q)",
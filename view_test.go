@@ -2,11 +2,16 @@ package hcat
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	hdep "github.com/hashicorp/hcat/dep"
 	dep "github.com/hashicorp/hcat/internal/dependency"
 )
 
@@ -108,6 +113,88 @@ func TestPoll_retries(t *testing.T) {
 	}
 }
 
+func TestPoll_noRetryOnExpiredToken(t *testing.T) {
+	d := &dep.FakeDepACLTokenExpired{}
+	vw := newView(&newViewInput{
+		Dependency: d,
+		RetryFunc: func(retry int) (bool, time.Duration) {
+			return true, 10 * time.Millisecond
+		},
+	})
+
+	viewCh := make(chan *view)
+	errCh := make(chan error)
+
+	go vw.poll(viewCh, errCh)
+	defer vw.stop()
+
+	select {
+	case <-viewCh:
+		t.Errorf("should not have gotten data")
+	case err := <-errCh:
+		if _, ok := err.(*hdep.ACLTokenExpiredError); !ok {
+			t.Errorf("expected an expired token error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if n := atomic.LoadInt32(&d.Fetches); n != 1 {
+		t.Errorf("expected 1 fetch, got %d", n)
+	}
+}
+
+func TestPoll_agentChangesBackToBack(t *testing.T) {
+	sleep := dep.AgentQuerySleepTime
+	dep.AgentQuerySleepTime = 10 * time.Millisecond
+	defer func() { dep.AgentQuerySleepTime = sleep }()
+
+	// a service and then its sidecar proxy register in quick succession
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/status/leader" {
+				fmt.Fprint(w, `"127.0.0.1:8300"`)
+				return
+			}
+			services := `"web": {"ID": "web", "Service": "web"}`
+			if atomic.AddInt32(&requests, 1) > 1 {
+				services += `, "web-proxy": {"ID": "web-proxy", "Service": "web-proxy"}`
+			}
+			fmt.Fprintf(w, "{%s}", services)
+		}))
+	defer srv.Close()
+
+	clients := NewClientSet()
+	if err := clients.AddConsul(ConsulInput{Address: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := dep.NewAgentServicesQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vw := newView(&newViewInput{Dependency: d, Clients: clients})
+
+	viewCh := make(chan *view)
+	errCh := make(chan error)
+
+	go vw.poll(viewCh, errCh)
+	defer vw.stop()
+
+	for _, exp := range []int{1, 2} {
+		select {
+		case v := <-viewCh:
+			if services := v.Data().([]*hdep.AgentService); len(services) != exp {
+				t.Fatalf("expected %d services, got %d", exp, len(services))
+			}
+		case err := <-errCh:
+			t.Fatalf("error while polling: %s", err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for %d services", exp)
+		}
+	}
+}
+
 func TestFetch_resetRetries(t *testing.T) {
 	view := newView(&newViewInput{
 		Dependency: &dep.FakeDepSameIndex{},
@@ -242,6 +242,16 @@ func (w *Watcher) Buffer(tmplID string) bool {
 	return w.bufferTemplates.Buffer(tmplID)
 }
 
+// Wake causes a Wait call to return so the template with the given ID can be
+// re-rendered. It is used by templates to signal changes that don't come from
+// a dependency, eg. Template.SetData.
+func (w *Watcher) Wake(tmplID string) {
+	select {
+	case w.bufferTrigger <- tmplID:
+	default:
+	}
+}
+
 // Register is used to add dependencies to be monitored by the watcher. It sets
 // everything up but stops short of running the polling, waiting for an
 // explicit start.
@@ -319,7 +329,7 @@ func (w *Watcher) Complete(n Notifier) bool {
 }
 
 // SetBufferPeriod sets a buffer period to accumulate dependency changes for
-// a template.
+// a template. Templates are identified by their ID, see TemplateInput.Name.
 func (w *Watcher) SetBufferPeriod(min, max time.Duration, tmplIDs ...string) {
 	for _, id := range tmplIDs {
 		w.bufferTemplates.Add(min, max, id)
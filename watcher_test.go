@@ -126,6 +126,23 @@ func TestWatcherWatching(t *testing.T) {
 			t.Errorf("should have 2 entries")
 		}
 	})
+	t.Run("named-templates-same-contents", func(t *testing.T) {
+		w := newWatcher(t)
+		defer w.Stop()
+
+		d := &idep.FakeDep{}
+		t0 := mustTemplate(t, TemplateInput{Name: "foo", Contents: "same"})
+		t1 := mustTemplate(t, TemplateInput{Name: "bar", Contents: "same"})
+		w.Register(t0, d)
+		w.Register(t1, d)
+
+		if len(w.tracker.tracked) != 2 {
+			t.Errorf("should have 2 entries")
+		}
+		if len(w.tracker.notifiers) != 2 {
+			t.Errorf("should have 2 notifiers")
+		}
+	})
 	t.Run("same-notifier-multiple-deps", func(t *testing.T) {
 		w := newWatcher(t)
 		defer w.Stop()
@@ -0,0 +1,80 @@
+package hcat
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// WriterRenderer renders the template text to an io.Writer, eg. stdout.
+type WriterRenderer struct {
+	writer    io.Writer
+	header    string
+	separator string
+
+	mu   sync.Mutex
+	last []byte
+	// rendered is true after the first successful render
+	rendered bool
+}
+
+// check for interface compliance
+var _ Renderer = (*WriterRenderer)(nil)
+
+// WriterRendererInput is the input structure for NewWriterRenderer.
+type WriterRendererInput struct {
+	// Writer is written to on each render (default os.Stdout)
+	Writer io.Writer
+	// Header is written before the contents of each render
+	Header string
+	// Separator is written between renders, before the Header
+	Separator string
+}
+
+// NewWriterRenderer returns a new WriterRenderer.
+func NewWriterRenderer(i WriterRendererInput) *WriterRenderer {
+	w := i.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	return &WriterRenderer{
+		writer:    w,
+		header:    i.Header,
+		separator: i.Separator,
+	}
+}
+
+// Render writes the contents to the writer if they differ from the last
+// contents written. Like the FileRenderer, unchanged contents are reported as
+// would render but did not.
+func (r *WriterRenderer) Render(contents []byte) (RenderResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rendered && bytes.Equal(r.last, contents) {
+		return RenderResult{
+			DidRender:   false,
+			WouldRender: true,
+		}, nil
+	}
+
+	var b bytes.Buffer
+	if r.rendered {
+		b.WriteString(r.separator)
+	}
+	b.WriteString(r.header)
+	b.Write(contents)
+	if _, err := r.writer.Write(b.Bytes()); err != nil {
+		return RenderResult{}, errors.Wrap(err, "failed writing contents")
+	}
+
+	r.last = append(r.last[:0], contents...)
+	r.rendered = true
+	return RenderResult{
+		DidRender:   true,
+		WouldRender: true,
+	}, nil
+}
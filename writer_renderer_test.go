@@ -0,0 +1,63 @@
+package hcat
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write error") }
+
+func TestWriterRenderer(t *testing.T) {
+	t.Run("header-separator", func(t *testing.T) {
+		var b bytes.Buffer
+		r := NewWriterRenderer(WriterRendererInput{
+			Writer: &b, Header: "# out\n", Separator: "---\n"})
+		cases := []struct {
+			contents string
+			did      bool
+		}{
+			{"one\n", true},
+			{"one\n", false},
+			{"two\n", true},
+		}
+		for _, tc := range cases {
+			rr, err := r.Render([]byte(tc.contents))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rr.DidRender != tc.did || !rr.WouldRender {
+				t.Fatalf("Bad render results; would: %v, did: %v",
+					rr.WouldRender, rr.DidRender)
+			}
+		}
+		exp := "# out\none\n---\n# out\ntwo\n"
+		if b.String() != exp {
+			t.Errorf("\nexp: %#v\nact: %#v", exp, b.String())
+		}
+	})
+	t.Run("empty-first", func(t *testing.T) {
+		var b bytes.Buffer
+		r := NewWriterRenderer(WriterRendererInput{Writer: &b, Header: "#\n"})
+		rr, err := r.Render(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rr.DidRender || b.String() != "#\n" {
+			t.Fatalf("empty contents not rendered: %v, %q", rr, b.String())
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		r := NewWriterRenderer(WriterRendererInput{Writer: errWriter{}})
+		rr, err := r.Render([]byte("foo"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if rr.DidRender || rr.WouldRender {
+			t.Fatalf("Bad render results; would: %v, did: %v",
+				rr.WouldRender, rr.DidRender)
+		}
+	})
+}